@@ -22,6 +22,13 @@ var blocksRotateCmd = &cobra.Command{
 	Long:  `Rotate blocks in the MPAGD project.`,
 }
 
+// blocksFlipCmd represents the subcommand for flipping blocks.
+var blocksFlipCmd = &cobra.Command{
+	Use:   "flip",
+	Short: "Flip blocks in the MPAGD project.",
+	Long:  `Flip blocks in the MPAGD project.`,
+}
+
 // Cmd_RotateBlockCCW90 creates a command to rotate a block 90 degrees counter-clockwise.
 func Cmd_RotateBlockCCW90() *cobra.Command {
 	var repeat int
@@ -198,6 +205,93 @@ func Cmd_RotateBlockCW90() *cobra.Command {
 	return cmd
 }
 
+// flipDirection returns the sub command name and description of a flip.
+func flipDirection(vertical bool) (string, string) {
+	if vertical {
+		return "v", "vertically"
+	}
+	return "h", "horizontally"
+}
+
+// Cmd_FlipBlock creates a command to mirror a block horizontally or vertically.
+func Cmd_FlipBlock(vertical bool) *cobra.Command {
+	var repeat int
+	var add bool
+	var startRange uint8
+	var endRange uint8
+	name, direction := flipDirection(vertical)
+
+	var cmd = &cobra.Command{
+		Use:   name + " [project file] [block number] [[output file]]",
+		Short: "Flip a block " + direction + ".",
+		Long:  `Flip a block ` + direction + `.`,
+		Args:  cobra.MinimumNArgs(2), // Ensure at least 2 arguments are provided
+		RunE: func(cmd *cobra.Command, args []string) error {
+			inFile := args[0]
+			blockNumber, err := strconv.Atoi(args[1])
+			if err != nil {
+				return fmt.Errorf("invalid block number: %w", err)
+			}
+			outFile := inFile // Default to the input file if no output file is provided
+			if len(args) == 3 {
+				outFile = args[2]
+			}
+
+			mpagd.LogMessage("Cmd_FlipBlock", fmt.Sprintf("Starting flip for file: %s", inFile), "info", noColor)
+
+			apjFile := mpagd.NewAPJFile(inFile)
+			err = apjFile.ReadAPJ()
+			if err != nil {
+				return fmt.Errorf("failed to read APJ file: %w", err)
+			}
+
+			// If the output file is the same as the input file, create a backup
+			if outFile == inFile {
+				err := apjFile.BackupProjectFile(false)
+				if err != nil {
+					return fmt.Errorf("failed to create backup: %w", err)
+				}
+				mpagd.LogMessage("Cmd_FlipBlock", fmt.Sprintf("Backup created: %s", inFile), "ok", noColor)
+			}
+
+			// Flip blocks
+			firstBlock, lastBlock := blockNumber, blockNumber+1
+			if startRange != 0 || endRange != 0 {
+				firstBlock, lastBlock = int(startRange), int(endRange)
+			}
+			for r := firstBlock; r < lastBlock; r++ {
+				currentBlock := r
+				for i := 0; i < repeat; i++ {
+					processedBlock, err := apjFile.FlipBlock(uint8(currentBlock), vertical, add)
+					if err != nil {
+						return fmt.Errorf("failed to flip block: %w", err)
+					}
+					if add {
+						currentBlock = int(processedBlock)
+						mpagd.LogMessage("Cmd_FlipBlock", fmt.Sprintf("created new flipped block: %v", currentBlock), "ok", noColor)
+					}
+				}
+			}
+
+			// Write the updated APJ file
+			err = apjFile.WriteAPJ(outFile)
+			if err != nil {
+				return fmt.Errorf("failed to write APJ file: %w", err)
+			}
+
+			mpagd.LogMessage("Cmd_FlipBlock", fmt.Sprintf("Flip completed for block number %d", blockNumber), "ok", noColor)
+			return nil
+		},
+	}
+
+	// Define flags for the command
+	cmd.Flags().IntVarP(&repeat, "repeat", "r", 1, "Repeat the operation this many times")
+	cmd.Flags().BoolVarP(&add, "add", "a", false, "Add each flipped block to the project file")
+	cmd.Flags().Uint8VarP(&startRange, "start", "s", 0, "Block start range for the operation")
+	cmd.Flags().Uint8VarP(&endRange, "end", "e", 0, "Block end range for the operation")
+	return cmd
+}
+
 // Cmd_ImportBlocks creates a command to import DEFINEBLOCK elements from an AGD file into an APJ file.
 func Cmd_ImportBlocks() *cobra.Command {
 	var replace bool
@@ -235,8 +329,8 @@ func Cmd_ImportBlocks() *cobra.Command {
 			// Import blocks from the AGD file
 			options := mpagd.CreateImportOptions()
 			options.SetIgnoreOptionsFalse()
-			options.SetIgnoreOptions(true, true, false, true, true, true, true, true, false)
-			options.SetOwOptions(false, false, replace, false, false, false, false, false, true)
+			options.SetIgnoreOptions(true, true, false, true, true, true, true, true, false, true)
+			options.SetOwOptions(false, false, replace, false, false, false, false, false, true, false)
 			if err := apj.ImportAGD(agdFilePath, options); err != nil {
 				return fmt.Errorf("failed to import blocks from AGD file: %w", err)
 			}
@@ -256,14 +350,91 @@ func Cmd_ImportBlocks() *cobra.Command {
 	return cmd
 }
 
+// Cmd_ImportBlocksPNG creates a command to import blocks from a PNG tileset.
+func Cmd_ImportBlocksPNG() *cobra.Command {
+	var replace bool
+	var typesStr string
+	var gap int
+
+	var cmd = &cobra.Command{
+		Use:   "import-png [project file] [png file] [[output file]]",
+		Short: "Import blocks from a PNG tileset into an APJ file.",
+		Long: `Cuts a PNG tileset into 8x8 cells and converts each cell into a Spectrum block.
+The most used colour in a cell becomes the paper and the other colour the ink.
+Cells that use more than two colours, or mix bright and normal colours, are reported.
+Use --types to set the block type per cell range, e.g. "0-3:WALLBLOCK,4:LADDERBLOCK,5-:PLATFORMBLOCK".
+Use --gap 1 to import a sheet written by blocks render-bmp.`,
+		Args: cobra.MinimumNArgs(2), // Ensure at least 2 arguments are provided
+		RunE: func(cmd *cobra.Command, args []string) error {
+			apjFilePath := args[0]
+			pngFilePath := args[1]
+			outputFilePath := apjFilePath // Default to the input file if no output file is provided
+			if len(args) == 3 {
+				outputFilePath = args[2]
+			}
+
+			types, err := mpagd.ParseBlockTypeRanges(typesStr)
+			if err != nil {
+				return fmt.Errorf("failed to parse block types: %w", err)
+			}
+
+			mpagd.LogMessage("Cmd_ImportBlocksPNG", fmt.Sprintf("Starting import for file: %s", apjFilePath), "info", noColor)
+
+			// Open and read the APJ file
+			apj := mpagd.NewAPJFile(apjFilePath)
+			if err := apj.ReadAPJ(); err != nil {
+				return fmt.Errorf("failed to read APJ file: %w", err)
+			}
+
+			// If the output file is the same as the input file, create a backup
+			if outputFilePath == apjFilePath {
+				err := apj.BackupProjectFile(false)
+				if err != nil {
+					return fmt.Errorf("failed to create backup: %w", err)
+				}
+				mpagd.LogMessage("Cmd_ImportBlocksPNG", fmt.Sprintf("Backup created: %s", outputFilePath), "ok", noColor)
+			}
+
+			// Import the blocks from the PNG file
+			firstBlock := len(apj.Blocks)
+			if replace {
+				firstBlock = 0
+			}
+			issues, err := apj.ImportBlocksFromPNG(pngFilePath, types, replace, gap)
+			if err != nil {
+				return fmt.Errorf("failed to import blocks from PNG file: %w", err)
+			}
+			for _, issue := range issues {
+				mpagd.LogMessage("Cmd_ImportBlocksPNG", fmt.Sprintf("Cell %d (column %d, row %d) %s, imported as block %d", issue.Cell, issue.Column, issue.Row, issue.Message, firstBlock+issue.Cell), "warning", noColor)
+			}
+
+			// Write the updated APJ file
+			if err := apj.WriteAPJ(outputFilePath); err != nil {
+				return fmt.Errorf("failed to write updated APJ file: %w", err)
+			}
+
+			mpagd.LogMessage("Cmd_ImportBlocksPNG", fmt.Sprintf("%d blocks imported successfully. Updated APJ file saved to %s", len(apj.Blocks)-firstBlock, outputFilePath), "ok", noColor)
+			return nil
+		},
+	}
+
+	// Define flags for the command
+	cmd.Flags().BoolVarP(&replace, "replace", "r", false, "Replace the current blocks")
+	cmd.Flags().StringVarP(&typesStr, "types", "t", "", "Block types per cell range, e.g. 0-3:WALLBLOCK,4-:PLATFORMBLOCK")
+	cmd.Flags().IntVarP(&gap, "gap", "g", 0, "Number of pixels between the cells")
+	return cmd
+}
+
 // Cmd_RenderBlock creates a command to render blocks to the terminal.
 func Cmd_RenderBlock() *cobra.Command {
 	var reorderStr string
+	var inline inlineImage
 
 	var cmd = &cobra.Command{
 		Use:   "render [project file] [[start block]] [[end block]]",
 		Short: "Render blocks to the terminal.",
-		Long:  `Render specific blocks from the project file to the terminal for visualization.`,
+		Long: `Render specific blocks from the project file to the terminal for visualization.
+Use --inline to show the blocks at full resolution on terminals with Sixel or Kitty graphics.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			projectFile := args[0]
 			startBlock := 0
@@ -310,7 +481,15 @@ func Cmd_RenderBlock() *cobra.Command {
 				reorder = mpagd.CSVToIntSlice(reorderStr)
 				mpagd.LogMessage("Cmd_RenderBlock", fmt.Sprintf("Reordering blocks: %v", reorder), "ok", noColor)
 			}
-			if err := apjFile.RenderBlockToTerminal(startBlock, endBlock, reorder); err != nil {
+			if inline.enabled() {
+				img, err := apjFile.RenderBlockImage(uint8(startBlock), uint8(endBlock), reorder, 0)
+				if err != nil {
+					return fmt.Errorf("failed to render blocks: %w", err)
+				}
+				if err := inline.write(cmd, img); err != nil {
+					return err
+				}
+			} else if err := apjFile.RenderBlockToTerminal(startBlock, endBlock, reorder); err != nil {
 				return fmt.Errorf("failed to render blocks: %w", err)
 			}
 			mpagd.LogMessage("Cmd_RenderBlock", fmt.Sprintf("Blocks %d to %d successfully rendered", startBlock, endBlock-1), "ok", noColor)
@@ -320,6 +499,7 @@ func Cmd_RenderBlock() *cobra.Command {
 
 	// Define flags for the command
 	cmd.Flags().StringVarP(&reorderStr, "reorder", "r", "", "Reorder the blocks in the output file")
+	addInlineFlags(cmd, &inline)
 	return cmd
 }
 
@@ -467,14 +647,218 @@ func Cmd_ReorderBlocks() *cobra.Command {
 	return cmd
 }
 
+// Cmd_DeleteBlocks creates a command to delete blocks from the MPAGD project.
+func Cmd_DeleteBlocks() *cobra.Command {
+	var substitute int
+	var force bool
+	var cmd = &cobra.Command{
+		Use:   "delete [project file] [block ids] [[output file]]",
+		Short: "Delete blocks from the MPAGD project.",
+		Long: `Delete blocks from the MPAGD project, e.g. "3,7,8". Screen cells and PUTBLOCK statements in the
+event code using a deleted block are changed to the substitute block, and those using a higher block
+are renumbered to match. Blocks are not deleted when the code puts a block from a variable, or from
+a constant such as UP for a block that moves, unless --force is used.`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			inFile := args[0]
+			outFile := inFile // Default to the input file if no output file is provided
+			if len(args) == 3 {
+				outFile = args[2]
+			}
+
+			ids, err := mpagd.ParseIntList(args[1])
+			if err != nil {
+				return fmt.Errorf("invalid block ids: %w", err)
+			}
+
+			apjFile := mpagd.NewAPJFile(inFile)
+			if err := apjFile.ReadAPJ(); err != nil {
+				return fmt.Errorf("failed to read APJ file: %w", err)
+			}
+
+			// Lines of code that can not be renumbered would put the wrong block after the delete
+			unmapped, err := apjFile.FindBlockReferences(apjFile.DeleteBlocksMapping(ids, substitute))
+			if err != nil {
+				return fmt.Errorf("failed to read the event code: %w", err)
+			}
+			logCodeReferences("Cmd_DeleteBlocks", "puts the block", unmapped)
+			if len(unmapped) > 0 && !force {
+				mpagd.LogMessage("Cmd_DeleteBlocks", "Blocks not deleted as the code puts blocks that can not be renumbered, use --force to delete them", "warning", noColor)
+				return nil
+			}
+
+			// If the output file is the same as the input file, create a backup including the code files
+			if outFile == inFile {
+				if err := apjFile.BackupProjectFile(true); err != nil {
+					return fmt.Errorf("failed to create backup: %w", err)
+				}
+				mpagd.LogMessage("Cmd_DeleteBlocks", fmt.Sprintf("Backup created: %s", inFile), "ok", noColor)
+			}
+
+			if err := apjFile.DeleteBlocks(ids, substitute, outFile); err != nil {
+				return fmt.Errorf("failed to delete blocks: %w", err)
+			}
+
+			if err := apjFile.WriteAPJ(outFile); err != nil {
+				return fmt.Errorf("failed to write APJ file: %w", err)
+			}
+
+			mpagd.LogMessage("Cmd_DeleteBlocks", fmt.Sprintf("%d blocks deleted successfully, %d blocks left", len(ids), apjFile.NrOfBlocks), "ok", noColor)
+			return nil
+		},
+	}
+	cmd.Flags().IntVarP(&substitute, "substitute", "s", 0, "Block used in place of the deleted blocks on the screens")
+	cmd.Flags().BoolVarP(&force, "force", "f", false, "Delete blocks even when the code puts blocks that can not be renumbered")
+	return cmd
+}
+
+// Cmd_DedupeBlocks creates a command to merge identical blocks in the MPAGD project.
+func Cmd_DedupeBlocks() *cobra.Command {
+	var dryRun bool
+	var spectrumOnly bool
+	var force bool
+	var cmd = &cobra.Command{
+		Use:   "dedupe [project file] [[output file]]",
+		Short: "Merge identical blocks in the MPAGD project.",
+		Long: `Find blocks with the same type and data on every platform and merge each one into the first block it matches.
+The screens and the PUTBLOCK statements in the event code are changed to use the block that is kept.
+Use --spectrum-only to compare only the Spectrum bitmap and attribute, and --dry-run to list the blocks that would be merged.
+Blocks are not merged when the code uses PUTBLOCK with a variable, unless --force is used.`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			inFile := args[0]
+			outFile := inFile // Default to the input file if no output file is provided
+			if len(args) == 2 {
+				outFile = args[1]
+			}
+
+			apjFile := mpagd.NewAPJFile(inFile)
+			if err := apjFile.ReadAPJ(); err != nil {
+				return fmt.Errorf("failed to read APJ file: %w", err)
+			}
+
+			duplicates := apjFile.FindDuplicateBlocks(spectrumOnly)
+			for _, duplicate := range duplicates {
+				mpagd.LogMessage("Cmd_DedupeBlocks", fmt.Sprintf("Block %d is the same as block %d", duplicate.ID, duplicate.Of), "info", noColor)
+			}
+			if dryRun || len(duplicates) == 0 {
+				mpagd.LogMessage("Cmd_DedupeBlocks", fmt.Sprintf("%d duplicate blocks found", len(duplicates)), "ok", noColor)
+				return nil
+			}
+
+			// Numbers in the code can not be changed safely when it uses variables for them
+			dynamic, err := apjFile.FindDynamicBlocks()
+			if err != nil {
+				return fmt.Errorf("failed to read the event code: %w", err)
+			}
+			logCodeReferences("Cmd_DedupeBlocks", "puts the block from", dynamic)
+			if len(dynamic) > 0 && !force {
+				mpagd.LogMessage("Cmd_DedupeBlocks", "Blocks not merged as the code puts blocks from variables, use --force to merge them", "warning", noColor)
+				return nil
+			}
+
+			// If the output file is the same as the input file, create a backup including the code files
+			if outFile == inFile {
+				if err := apjFile.BackupProjectFile(true); err != nil {
+					return fmt.Errorf("failed to create backup: %w", err)
+				}
+				mpagd.LogMessage("Cmd_DedupeBlocks", fmt.Sprintf("Backup created: %s", inFile), "ok", noColor)
+			}
+
+			if err := apjFile.MergeBlocks(duplicates, outFile); err != nil {
+				return fmt.Errorf("failed to merge blocks: %w", err)
+			}
+
+			if err := apjFile.WriteAPJ(outFile); err != nil {
+				return fmt.Errorf("failed to write APJ file: %w", err)
+			}
+
+			mpagd.LogMessage("Cmd_DedupeBlocks", fmt.Sprintf("%d duplicate blocks merged successfully, %d blocks left", len(duplicates), apjFile.NrOfBlocks), "ok", noColor)
+			return nil
+		},
+	}
+	cmd.Flags().BoolVarP(&dryRun, "dry-run", "d", false, "List the blocks that would be merged without changing the project")
+	cmd.Flags().BoolVarP(&spectrumOnly, "spectrum-only", "s", false, "Only compare the Spectrum data, the data of the other platforms of the duplicates is lost")
+	cmd.Flags().BoolVarP(&force, "force", "f", false, "Merge blocks even when the code uses variables for them")
+	return cmd
+}
+
+// Cmd_BlocksFromSprite creates a command to cut a sprite frame into four blocks.
+func Cmd_BlocksFromSprite() *cobra.Command {
+	var typeName string
+	var attr uint8
+	var cmd = &cobra.Command{
+		Use:   "from-sprite [project file] [sprite number] [frame] [[output file]]",
+		Short: "Cut a sprite frame into four blocks.",
+		Long: `Cut a 16x16 sprite frame into four new 8x8 blocks, added in the order top left, top right,
+bottom left and bottom right. The blocks get the block type and Spectrum attribute given by the flags.`,
+		Args: cobra.RangeArgs(3, 4),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			inFile := args[0]
+			outFile := inFile // Default to the input file if no output file is provided
+			if len(args) == 4 {
+				outFile = args[3]
+			}
+
+			spriteNumber, err := strconv.Atoi(args[1])
+			if err != nil {
+				return fmt.Errorf("invalid sprite number: %w", err)
+			}
+			frame, err := strconv.Atoi(args[2])
+			if err != nil {
+				return fmt.Errorf("invalid frame: %w", err)
+			}
+			blockType, err := mpagd.ParseBlockType(typeName)
+			if err != nil {
+				return err
+			}
+
+			apjFile := mpagd.NewAPJFile(inFile)
+			if err := apjFile.ReadAPJ(); err != nil {
+				return fmt.Errorf("failed to read APJ file: %w", err)
+			}
+
+			// If the output file is the same as the input file, create a backup
+			if outFile == inFile {
+				if err := apjFile.BackupProjectFile(false); err != nil {
+					return fmt.Errorf("failed to create backup: %w", err)
+				}
+				mpagd.LogMessage("Cmd_BlocksFromSprite", fmt.Sprintf("Backup created: %s", inFile), "ok", noColor)
+			}
+
+			first, err := apjFile.BlocksFromSprite(spriteNumber, frame, blockType, attr)
+			if err != nil {
+				return fmt.Errorf("failed to cut sprite into blocks: %w", err)
+			}
+
+			if err := apjFile.WriteAPJ(outFile); err != nil {
+				return fmt.Errorf("failed to write APJ file: %w", err)
+			}
+
+			mpagd.LogMessage("Cmd_BlocksFromSprite", fmt.Sprintf("Frame %d of sprite %d cut into blocks %d to %d", frame, spriteNumber, first, first+3), "ok", noColor)
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&typeName, "type", "t", "EMPTYBLOCK", "Block type of the new blocks, by name or number")
+	cmd.Flags().Uint8VarP(&attr, "attr", "a", 71, "Spectrum attribute of the new blocks, 71 is bright white ink on black paper")
+	return cmd
+}
+
 // Initialize the commands and add them to the root command.
 func init() {
 	RootCmd.AddCommand(blocksCmd)
 	blocksCmd.AddCommand(Cmd_ImportBlocks())
+	blocksCmd.AddCommand(Cmd_ImportBlocksPNG())
 	blocksCmd.AddCommand(blocksRotateCmd)
 	blocksRotateCmd.AddCommand(Cmd_RotateBlockCCW90())
 	blocksRotateCmd.AddCommand(Cmd_RotateBlockCW90())
+	blocksCmd.AddCommand(blocksFlipCmd)
+	blocksFlipCmd.AddCommand(Cmd_FlipBlock(false))
+	blocksFlipCmd.AddCommand(Cmd_FlipBlock(true))
 	blocksCmd.AddCommand(Cmd_RenderBlock())
 	blocksCmd.AddCommand(Cmd_RenderBlocksToBitmap())
 	blocksCmd.AddCommand(Cmd_ReorderBlocks())
+	blocksCmd.AddCommand(Cmd_DeleteBlocks())
+	blocksCmd.AddCommand(Cmd_DedupeBlocks())
+	blocksCmd.AddCommand(Cmd_BlocksFromSprite())
 }
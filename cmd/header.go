@@ -53,8 +53,8 @@ func Cmd_ImportHeader() *cobra.Command {
 
 			// Configure import options.
 			options := mpagd.CreateImportOptions()
-			options.SetIgnoreOptions(false, true, true, true, true, true, true, true, true)
-			options.SetOwOptions(replace, false, false, false, false, false, false, false, false)
+			options.SetIgnoreOptions(false, true, true, true, true, true, true, true, true, true)
+			options.SetOwOptions(replace, false, false, false, false, false, false, false, false, false)
 
 			// Perform the import operation.
 			if err := apj.ImportAGD(agdFilePath, options); err != nil {
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/Mrpye/mpagd_util/mpagd"
 	"github.com/spf13/cobra"
@@ -49,14 +50,28 @@ func Cmd_ImportMap() *cobra.Command {
 
 			// Configure import options
 			options := mpagd.CreateImportOptions()
-			options.SetIgnoreOptions(true, true, true, true, true, true, true, false, true)
-			options.SetOwOptions(false, false, false, false, false, false, false, replace, false)
+			options.SetIgnoreOptions(true, true, true, true, true, true, false, true, true, true)
+			options.SetOwOptions(false, false, false, false, false, false, replace, false, false, false)
 
 			// Perform the import operation
 			if err := apj.ImportAGD(agdFilePath, options); err != nil {
 				return fmt.Errorf("failed to import Map from AGD file: %w", err)
 			}
 
+			// Warn about map cells that point at screens the project does not have
+			for y, row := range apj.Map.Map {
+				for x, screen := range row {
+					if screen != 255 && int(screen) >= len(apj.Screens) {
+						mpagd.LogMessage("Cmd_ImportMap", fmt.Sprintf("Map cell %d,%d uses screen %d which does not exist", x, y, screen), "warning", noColor)
+					}
+				}
+			}
+
+			// Write the updated APJ file
+			if err := apj.WriteAPJ(outputFilePath); err != nil {
+				return fmt.Errorf("failed to write APJ file: %w", err)
+			}
+
 			// Log the successful completion of the import
 			mpagd.LogMessage("Cmd_ImportMap", fmt.Sprintf("Map imported successfully. Updated APJ file saved to %s", outputFilePath), "ok", noColor)
 			return nil
@@ -68,8 +83,88 @@ func Cmd_ImportMap() *cobra.Command {
 	return cmd
 }
 
+// Cmd_RenderMapToBitmap creates a command to render the whole map of an APJ file to a bitmap image.
+func Cmd_RenderMapToBitmap() *cobra.Command {
+	var options mpagd.MapRenderOptions
+	var cmd = &cobra.Command{
+		Use:   "render-bmp [apj file] [bitmap file]",
+		Short: "Render the whole map from an APJ file to a bitmap image.",
+		Long: `Renders every screen of the map in its place on the map and saves it as a bitmap image in PNG format.
+Empty map cells are hatched in grey, cells using a missing screen are hatched in red and the start screen is outlined in yellow.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Parse input arguments
+			apjFilePath := args[0]
+			outputFilePath := args[1]
+
+			// Log the start of the render process
+			mpagd.LogMessage("Cmd_RenderMapToBitmap", fmt.Sprintf("Starting map render for file: %s", apjFilePath), "info", noColor)
+
+			// Read the APJ file
+			apj := mpagd.NewAPJFile(apjFilePath)
+			if err := apj.ReadAPJ(); err != nil {
+				return fmt.Errorf("failed to read APJ file: %w", err)
+			}
+
+			// Render the map to a bitmap image
+			if err := apj.RenderMapToBitmap(outputFilePath, options); err != nil {
+				return fmt.Errorf("failed to render map to bitmap: %w", err)
+			}
+
+			// Log the successful completion of the render
+			mpagd.LogMessage("Cmd_RenderMapToBitmap", fmt.Sprintf("Map rendered successfully. Output saved to %s", outputFilePath), "ok", noColor)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVarP(&options.Labels, "labels", "l", false, "Write the screen number on each screen")
+	cmd.Flags().BoolVarP(&options.Grid, "grid", "g", false, "Draw grid lines between the screens")
+	return cmd
+}
+
+// Cmd_RenderMap creates a command to render the whole map of an APJ file to the terminal.
+func Cmd_RenderMap() *cobra.Command {
+	var options mpagd.MapRenderOptions
+	var inline inlineImage
+	var cmd = &cobra.Command{
+		Use:   "render [apj file]",
+		Short: "Render the whole map to the terminal.",
+		Long: `Renders every screen of the map in its place on the map to the terminal, two rows of pixels per line.
+Use --mono, or set NO_COLOR, on terminals without 24-bit colour, and --inline to show the map at full resolution on terminals with Sixel or Kitty graphics.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			apj := mpagd.NewAPJFile(args[0])
+			if err := apj.ReadAPJ(); err != nil {
+				return fmt.Errorf("failed to read APJ file: %w", err)
+			}
+
+			mpagd.LogMessage("Cmd_RenderMap", fmt.Sprintf("Rendering map of %s", args[0]), "info", noColor)
+			if inline.enabled() {
+				img, err := apj.RenderMapImage(options)
+				if err != nil {
+					return fmt.Errorf("failed to render map: %w", err)
+				}
+				return inline.write(cmd, img)
+			}
+			colour := !options.Mono && os.Getenv("NO_COLOR") == ""
+			if err := apj.RenderMapToTerminal(cmd.OutOrStdout(), options, colour); err != nil {
+				return fmt.Errorf("failed to render map: %w", err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVarP(&options.Labels, "labels", "l", false, "Write the screen number on each screen")
+	cmd.Flags().BoolVarP(&options.Grid, "grid", "g", false, "Draw grid lines between the screens")
+	cmd.Flags().BoolVarP(&options.Mono, "mono", "m", false, "Render without colours, for terminals without colour support")
+	addInlineFlags(cmd, &inline)
+	return cmd
+}
+
 func init() {
 	// Register the map command and its subcommands
 	RootCmd.AddCommand(mapCmd)
 	mapCmd.AddCommand(Cmd_ImportMap())
+	mapCmd.AddCommand(Cmd_RenderMapToBitmap())
+	mapCmd.AddCommand(Cmd_RenderMap())
 }
@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/Mrpye/mpagd_util/mpagd"
+	"github.com/spf13/cobra"
+)
+
+// messagesCmd is the root command for managing messages in the MPAGD project.
+var messagesCmd = &cobra.Command{
+	Use:   "messages",
+	Short: "Manage messages in the MPAGD project.",
+	Long:  `Manage messages in the MPAGD project. Messages are stored in a .msg file next to the project file.`,
+}
+
+// Cmd_ListMessages creates a command to list the messages in the project file.
+func Cmd_ListMessages() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list [project file]",
+		Short: "List the messages in the project file.",
+		Long:  `List the messages in the project file with their message numbers.`,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			apj := mpagd.NewAPJFile(args[0])
+			if err := apj.ReadAPJ(); err != nil {
+				return fmt.Errorf("failed to read APJ file: %w", err)
+			}
+
+			mpagd.LogMessage("Cmd_ListMessages", fmt.Sprintf("Messages in %s: %d", args[0], len(apj.Messages)), "info", noColor)
+			for _, message := range apj.Messages {
+				fmt.Printf("%3d: \"%s\"\n", message.ID, message.Text)
+			}
+			return nil
+		},
+	}
+}
+
+// Cmd_AddMessage creates a command to add a message to the project file.
+func Cmd_AddMessage() *cobra.Command {
+	var index int
+	var cmd = &cobra.Command{
+		Use:   "add [project file] [text] [[output file]]",
+		Short: "Add a message to the project file.",
+		Long: `Add a message to the end of the message list, or insert it at the given index.
+The MESSAGE statements in the event code are renumbered to match.`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			inFile := args[0]
+			outFile := inFile
+			if len(args) == 3 {
+				outFile = args[2]
+			}
+
+			apj, err := readProjectForUpdate("Cmd_AddMessage", inFile, outFile, true)
+			if err != nil {
+				return err
+			}
+
+			id, unchanged, err := apj.AddMessage(args[1], index, outFile)
+			if err != nil {
+				return fmt.Errorf("failed to add message: %w", err)
+			}
+			logCodeReferences("Cmd_AddMessage", "displays the message", unchanged)
+
+			if err := apj.WriteAPJ(outFile); err != nil {
+				return fmt.Errorf("failed to write APJ file: %w", err)
+			}
+
+			mpagd.LogMessage("Cmd_AddMessage", fmt.Sprintf("Message %d added successfully", id), "ok", noColor)
+			return nil
+		},
+	}
+	cmd.Flags().IntVarP(&index, "index", "i", -1, "Insert the message at this index instead of appending it")
+	return cmd
+}
+
+// Cmd_EditMessage creates a command to change the text of a message.
+func Cmd_EditMessage() *cobra.Command {
+	return &cobra.Command{
+		Use:   "edit [project file] [message number] [text] [[output file]]",
+		Short: "Edit a message in the project file.",
+		Long:  `Replace the text of a message in the project file.`,
+		Args:  cobra.MinimumNArgs(3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			inFile := args[0]
+			outFile := inFile
+			if len(args) == 4 {
+				outFile = args[3]
+			}
+
+			id, err := strconv.Atoi(args[1])
+			if err != nil {
+				return fmt.Errorf("invalid message number: %w", err)
+			}
+
+			apj, err := readProjectForUpdate("Cmd_EditMessage", inFile, outFile, false)
+			if err != nil {
+				return err
+			}
+
+			if err := apj.EditMessage(id, args[2]); err != nil {
+				return fmt.Errorf("failed to edit message: %w", err)
+			}
+
+			if err := apj.WriteAPJ(outFile); err != nil {
+				return fmt.Errorf("failed to write APJ file: %w", err)
+			}
+
+			mpagd.LogMessage("Cmd_EditMessage", fmt.Sprintf("Message %d updated successfully", id), "ok", noColor)
+			return nil
+		},
+	}
+}
+
+// Cmd_DeleteMessage creates a command to delete a message from the project file.
+func Cmd_DeleteMessage() *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete [project file] [message number] [[output file]]",
+		Short: "Delete a message from the project file.",
+		Long: `Delete a message from the project file. The messages after it and the MESSAGE statements
+in the event code are renumbered. A warning is shown for each MESSAGE statement that still uses the deleted message.`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			inFile := args[0]
+			outFile := inFile
+			if len(args) == 3 {
+				outFile = args[2]
+			}
+
+			id, err := strconv.Atoi(args[1])
+			if err != nil {
+				return fmt.Errorf("invalid message number: %w", err)
+			}
+
+			apj, err := readProjectForUpdate("Cmd_DeleteMessage", inFile, outFile, true)
+			if err != nil {
+				return err
+			}
+
+			unchanged, err := apj.DeleteMessage(id, outFile)
+			if err != nil {
+				return fmt.Errorf("failed to delete message: %w", err)
+			}
+			logCodeReferences("Cmd_DeleteMessage", "displays the message", unchanged)
+
+			if err := apj.WriteAPJ(outFile); err != nil {
+				return fmt.Errorf("failed to write APJ file: %w", err)
+			}
+
+			mpagd.LogMessage("Cmd_DeleteMessage", fmt.Sprintf("Message %d deleted successfully", id), "ok", noColor)
+			return nil
+		},
+	}
+}
+
+// Cmd_ReorderMessages creates a command to reorder the messages in the project file.
+func Cmd_ReorderMessages() *cobra.Command {
+	return &cobra.Command{
+		Use:   "reorder [project file] [order] [[output file]]",
+		Short: "Reorder messages in the project file.",
+		Long: `Reorder messages in the project file based on the specified order, e.g. "2,0,1".
+The MESSAGE statements in the event code are renumbered to match.`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			inFile := args[0]
+			outFile := inFile
+			if len(args) == 3 {
+				outFile = args[2]
+			}
+
+			apj, err := readProjectForUpdate("Cmd_ReorderMessages", inFile, outFile, true)
+			if err != nil {
+				return err
+			}
+
+			unchanged, err := apj.ReorderMessages(mpagd.CSVToIntSlice(args[1]), outFile)
+			if err != nil {
+				return fmt.Errorf("failed to reorder messages: %w", err)
+			}
+			logCodeReferences("Cmd_ReorderMessages", "displays the message", unchanged)
+
+			if err := apj.WriteAPJ(outFile); err != nil {
+				return fmt.Errorf("failed to write APJ file: %w", err)
+			}
+
+			mpagd.LogMessage("Cmd_ReorderMessages", "Reorder completed successfully", "ok", noColor)
+			return nil
+		},
+	}
+}
+
+// init initializes the messages command and adds it to the root command.
+func init() {
+	RootCmd.AddCommand(messagesCmd)
+	messagesCmd.AddCommand(Cmd_ListMessages())
+	messagesCmd.AddCommand(Cmd_AddMessage())
+	messagesCmd.AddCommand(Cmd_EditMessage())
+	messagesCmd.AddCommand(Cmd_DeleteMessage())
+	messagesCmd.AddCommand(Cmd_ReorderMessages())
+}
@@ -53,8 +53,8 @@ func Cmd_ImportObjects() *cobra.Command {
 
 			// Configure import options.
 			options := mpagd.CreateImportOptions()
-			options.SetIgnoreOptions(true, true, true, true, true, false, true, true, true)
-			options.SetOwOptions(false, false, false, false, false, replace, false, false, false)
+			options.SetIgnoreOptions(true, true, true, true, true, false, true, true, true, true)
+			options.SetOwOptions(false, false, false, false, false, replace, false, false, false, false)
 
 			// Perform the import operation.
 			if err := apj.ImportAGD(agdFilePath, options); err != nil {
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/Mrpye/mpagd_util/mpagd"
@@ -250,12 +251,13 @@ func Cmd_LoadYAML() *cobra.Command {
 
 // Cmd_ImportAGD creates a command to import all AGD elements into the project file.
 func Cmd_ImportAGD() *cobra.Command {
-	var replace bool
+	var replace, overwriteCode, noCode bool
 	var cmd = &cobra.Command{
 		Use:   "import [project file] [agd file] [[output project file]]",
 		Short: "Import all AGD elements into the project file.",
 		Args:  cobra.MinimumNArgs(2), // Ensure at least 2 arguments are provided
-		Long:  `Import all AGD elements (blocks, sprites, screens, etc.) into the project file and save the updated project.`,
+		Long: `Import all AGD elements (blocks, sprites, screens, etc.) into the project file and save the updated project.
+The EVENT sections are written to the project's .aNN code files, existing code files are kept unless --overwrite-code is set.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			projectFile := args[0]
 			agdFile := args[1]
@@ -267,9 +269,10 @@ func Cmd_ImportAGD() *cobra.Command {
 			// Open and read the project file
 			apj := mpagd.NewAPJFile(projectFile)
 
+			overwrite := replace
 			if err := apj.ReadAPJ(); err != nil {
 				mpagd.LogMessage("Cmd_ImportAGD", fmt.Sprintf("Project file %s does not exist", projectFile), "warning", noColor)
-				replace = true // Set replace to false if the project file does not exist
+				overwrite = true // Replace everything if the project file does not exist
 			}
 
 			// If the output file is the same as the input file, create a backup
@@ -289,7 +292,7 @@ func Cmd_ImportAGD() *cobra.Command {
 			// Import all AGD elements
 			options := mpagd.CreateImportOptions()
 			options.SetIgnoreOptionsFalse() // Import all elements
-			options.SetOwOptions(replace, replace, replace, replace, replace, replace, replace, replace, replace)
+			options.SetOwOptions(overwrite, overwrite, overwrite, overwrite, overwrite, overwrite, overwrite, overwrite, overwrite, overwrite)
 			if err := apj.ImportAGD(agdFile, options); err != nil {
 				return fmt.Errorf("failed to import AGD elements: %w", err)
 			}
@@ -299,18 +302,31 @@ func Cmd_ImportAGD() *cobra.Command {
 				return fmt.Errorf("failed to write updated project file: %w", err)
 			}
 
+			// Split the EVENT sections into the code files of the output project
+			if !noCode {
+				outApj := mpagd.NewAPJFile(outputFile)
+				outApj.SetNoColorOutput(noColor)
+				written, err := outApj.ImportEvents(agdFile, overwriteCode)
+				if err != nil {
+					return fmt.Errorf("failed to import events: %w", err)
+				}
+				mpagd.LogMessage("Cmd_ImportAGD", fmt.Sprintf("%d event code files written", len(written)), "ok", noColor)
+			}
+
 			mpagd.LogMessage("Cmd_ImportAGD", fmt.Sprintf("AGD elements imported successfully. Updated project file saved to %s", outputFile), "ok", noColor)
 			return nil
 		},
 	}
 	cmd.Flags().BoolVarP(&replace, "replace", "r", false, "Replace existing elements in the project file")
+	cmd.Flags().BoolVar(&overwriteCode, "overwrite-code", false, "Overwrite existing .aNN code files with the AGD events")
+	cmd.Flags().BoolVar(&noCode, "no-code", false, "Do not write the AGD events to .aNN code files")
 	return cmd
 }
 
 // Cmd_ImportAGDSelective creates a command to import selected AGD elements into the project file.
 func Cmd_ImportAGDSelective() *cobra.Command {
 	var replace bool
-	var importBlocks, importSprites, importScreens, importObjects, importMaps, importFonts, importULAPalette, importWindows, importKeys bool
+	var importBlocks, importSprites, importScreens, importObjects, importMaps, importFonts, importULAPalette, importWindows, importKeys, importMessages, importCode bool
 
 	var cmd = &cobra.Command{
 		Use:   "import-selective [project file] [agd file] [[output project file]]",
@@ -327,9 +343,10 @@ func Cmd_ImportAGDSelective() *cobra.Command {
 			mpagd.LogMessage("Cmd_ImportAGDSelective", fmt.Sprintf("Importing AGD elements from %s to %s", agdFile, projectFile), "info", noColor)
 			// Open and read the project file
 			apj := mpagd.NewAPJFile(projectFile)
+			overwrite := replace
 			if err := apj.ReadAPJ(); err != nil {
 				mpagd.LogMessage("Cmd_ImportAGDSelective", fmt.Sprintf("Project file %s does not exist", projectFile), "warning", noColor)
-				replace = true // Set replace to false if the project file does not exist
+				overwrite = true // Replace everything if the project file does not exist
 			}
 
 			// If the output file is the same as the input file, create a backup
@@ -347,8 +364,8 @@ func Cmd_ImportAGDSelective() *cobra.Command {
 
 			// Import selected AGD elements
 			options := mpagd.CreateImportOptions()
-			options.SetIgnoreOptions(!importWindows, !importKeys, !importBlocks, !importSprites, !importObjects, !importScreens, !importMaps, !importFonts, !importULAPalette)
-			options.SetOwOptions(replace, replace, replace, replace, replace, replace, replace, replace, replace)
+			options.SetIgnoreOptions(!importWindows, !importKeys, !importBlocks, !importSprites, !importObjects, !importScreens, !importMaps, !importFonts, !importULAPalette, !importMessages)
+			options.SetOwOptions(overwrite, overwrite, overwrite, overwrite, overwrite, overwrite, overwrite, overwrite, overwrite, overwrite)
 
 			if err := apj.ImportAGD(agdFile, options); err != nil {
 				return fmt.Errorf("failed to import AGD elements: %w", err)
@@ -359,6 +376,17 @@ func Cmd_ImportAGDSelective() *cobra.Command {
 				return fmt.Errorf("failed to write updated project file: %w", err)
 			}
 
+			// Split the EVENT sections into the code files of the output project
+			if importCode {
+				outApj := mpagd.NewAPJFile(outputFile)
+				outApj.SetNoColorOutput(noColor)
+				written, err := outApj.ImportEvents(agdFile, replace)
+				if err != nil {
+					return fmt.Errorf("failed to import events: %w", err)
+				}
+				mpagd.LogMessage("Cmd_ImportAGDSelective", fmt.Sprintf("%d event code files written", len(written)), "ok", noColor)
+			}
+
 			mpagd.LogMessage("Cmd_ImportAGDSelective", fmt.Sprintf("AGD elements imported successfully. Updated project file saved to %s", outputFile), "ok", noColor)
 			return nil
 		},
@@ -375,10 +403,42 @@ func Cmd_ImportAGDSelective() *cobra.Command {
 	cmd.Flags().BoolVar(&importMaps, "maps", false, "Import maps")
 	cmd.Flags().BoolVar(&importFonts, "fonts", false, "Import fonts")
 	cmd.Flags().BoolVar(&importULAPalette, "ula-palette", false, "Import ULA palette")
+	cmd.Flags().BoolVar(&importMessages, "messages", false, "Import messages")
+	cmd.Flags().BoolVar(&importCode, "code", false, "Import the events into the .aNN code files, --replace overwrites existing code files")
 
 	return cmd
 }
 
+// Cmd_ExportAGD creates a command to export the project file as an AGD source file.
+func Cmd_ExportAGD() *cobra.Command {
+	return &cobra.Command{
+		Use:   "export-agd [project file] [agd file]",
+		Short: "Export the project file to an AGD file.",
+		Args:  cobra.ExactArgs(2),
+		Long:  `Export all project elements (window, controls, blocks, sprites, objects, screens, map, font, palette and event code) to an AGD source file.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			projectFile := args[0]
+			agdFile := args[1]
+			// Check if the project file exists
+			if _, err := os.Stat(projectFile); os.IsNotExist(err) {
+				return fmt.Errorf("file %s does not exist", projectFile)
+			}
+			mpagd.LogMessage("Cmd_ExportAGD", fmt.Sprintf("Exporting project %s to %s", projectFile, agdFile), "info", noColor)
+			apj := mpagd.NewAPJFile(projectFile)
+			if err := apj.ReadAPJ(); err != nil {
+				return fmt.Errorf("failed to read project file: %w", err)
+			}
+
+			if err := apj.ExportAGD(agdFile); err != nil {
+				return fmt.Errorf("failed to export AGD file: %w", err)
+			}
+
+			mpagd.LogMessage("Cmd_ExportAGD", fmt.Sprintf("Project exported successfully to %s", agdFile), "ok", noColor)
+			return nil
+		},
+	}
+}
+
 // Cmd_ListTemplates creates a command to list all available project templates.
 func Cmd_ListTemplates() *cobra.Command {
 	return &cobra.Command{
@@ -454,6 +514,164 @@ func Cmd_ProjectStats() *cobra.Command {
 	}
 }
 
+// Cmd_DiffProjects creates a command to list the differences between two project files.
+func Cmd_DiffProjects() *cobra.Command {
+	var jsonOutput bool
+	var cmd = &cobra.Command{
+		Use:   "diff [project file] [other project file]",
+		Short: "Show the differences between two project files.",
+		Long: `Compare every section of two project files, down to individual blocks, sprite frames,
+screen cells and map cells, and print the differences as text or JSON.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			projects := make([]*mpagd.APJFile, len(args))
+			for i, projectFile := range args {
+				projects[i] = mpagd.NewAPJFile(projectFile)
+				if err := projects[i].ReadAPJ(); err != nil {
+					return fmt.Errorf("failed to read project file %s: %w", projectFile, err)
+				}
+			}
+
+			diff := projects[0].Diff(projects[1])
+			if jsonOutput {
+				return diff.WriteJSON(os.Stdout)
+			}
+			return diff.WriteText(os.Stdout)
+		},
+	}
+	cmd.Flags().BoolVarP(&jsonOutput, "json", "j", false, "Output the differences as JSON")
+	return cmd
+}
+
+// Cmd_ValidateProject creates a command to check a project file for problems.
+func Cmd_ValidateProject() *cobra.Command {
+	var strict bool
+	var cmd = &cobra.Command{
+		Use:   "validate [project file]",
+		Short: "Check a project file for problems.",
+		Long: `Check a project file for problems that break the game or the tools, such as screen cells
+using blocks that do not exist, sprite positions using missing sprites or screens, map cells
+using missing screens, screens that do not match the window and counts over the 255 limit.
+The command exits with an error when problems are found, so it can be used as a pre-commit hook.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			projectFile := args[0]
+			apj := mpagd.NewAPJFile(projectFile)
+			if err := apj.ReadAPJ(); err != nil {
+				return fmt.Errorf("failed to read project file: %w", err)
+			}
+
+			issues := apj.Validate()
+			errorCount := 0
+			for _, issue := range issues {
+				if issue.Level == "error" {
+					errorCount++
+				}
+				mpagd.LogMessage("Cmd_ValidateProject", fmt.Sprintf("%s: %s", issue.Section, issue.Message), issue.Level, noColor)
+			}
+			warningCount := len(issues) - errorCount
+
+			if errorCount > 0 || (strict && warningCount > 0) {
+				return fmt.Errorf("validation failed: %d errors, %d warnings", errorCount, warningCount)
+			}
+			mpagd.LogMessage("Cmd_ValidateProject", fmt.Sprintf("%s is valid, %d warnings", projectFile, warningCount), "ok", noColor)
+			return nil
+		},
+	}
+	cmd.Flags().BoolVarP(&strict, "strict", "s", false, "Fail on warnings as well as errors")
+	return cmd
+}
+
+// Cmd_UnusedProject creates a command to list, and optionally delete, the unused blocks and sprites.
+func Cmd_UnusedProject() *cobra.Command {
+	var purge bool
+	var force bool
+	var cmd = &cobra.Command{
+		Use:   "unused [project file] [[output file]]",
+		Short: "List the blocks and sprites the project does not use.",
+		Long: `List the blocks that are not used on any screen or by PUTBLOCK, and the sprites that are not placed
+on any screen or used by IMAGE or SPAWN in the event code files. With --purge they are deleted and
+the screens, sprite positions and event code are renumbered to match.
+Sprites are not purged when the code sets IMAGE from a variable, and blocks are not purged when the
+code uses PUTBLOCK with a variable, unless --force is used.`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			inFile := args[0]
+			outFile := inFile // Default to the input file if no output file is provided
+			if len(args) == 2 {
+				outFile = args[1]
+			}
+
+			apjFile := mpagd.NewAPJFile(inFile)
+			if err := apjFile.ReadAPJ(); err != nil {
+				return fmt.Errorf("failed to read APJ file: %w", err)
+			}
+
+			report, err := apjFile.FindUnused()
+			if err != nil {
+				return fmt.Errorf("failed to find unused blocks and sprites: %w", err)
+			}
+			mpagd.LogMessage("Cmd_UnusedProject", fmt.Sprintf("Unused blocks: %s", formatIDs(report.Blocks)), "info", noColor)
+			mpagd.LogMessage("Cmd_UnusedProject", fmt.Sprintf("Unused sprites: %s", formatIDs(report.Sprites)), "info", noColor)
+			logCodeReferences("Cmd_UnusedProject", "sets the image from", report.DynamicImages)
+			logCodeReferences("Cmd_UnusedProject", "puts the block from", report.DynamicBlocks)
+			if !purge {
+				return nil
+			}
+
+			purgeBlocks := len(report.DynamicBlocks) == 0 || force
+			if !purgeBlocks && len(report.Blocks) > 0 {
+				mpagd.LogMessage("Cmd_UnusedProject", "Blocks not purged as the code puts blocks from variables, use --force to purge them", "warning", noColor)
+			}
+			purgeSprites := len(report.DynamicImages) == 0 || force
+			if !purgeSprites && len(report.Sprites) > 0 {
+				mpagd.LogMessage("Cmd_UnusedProject", "Sprites not purged as the code sets images from variables, use --force to purge them", "warning", noColor)
+			}
+
+			// If the output file is the same as the input file, create a backup including the code files
+			if outFile == inFile {
+				if err := apjFile.BackupProjectFile(true); err != nil {
+					return fmt.Errorf("failed to create backup: %w", err)
+				}
+				mpagd.LogMessage("Cmd_UnusedProject", fmt.Sprintf("Backup created: %s", inFile), "ok", noColor)
+			}
+
+			if err := apjFile.PurgeUnused(report, purgeBlocks, purgeSprites, outFile); err != nil {
+				return fmt.Errorf("failed to purge unused blocks and sprites: %w", err)
+			}
+
+			if err := apjFile.WriteAPJ(outFile); err != nil {
+				return fmt.Errorf("failed to write APJ file: %w", err)
+			}
+
+			mpagd.LogMessage("Cmd_UnusedProject", fmt.Sprintf("Purge completed successfully, %d blocks and %d sprites left", apjFile.NrOfBlocks, apjFile.NrOfSprites), "ok", noColor)
+			return nil
+		},
+	}
+	cmd.Flags().BoolVarP(&purge, "purge", "p", false, "Delete the unused blocks and sprites")
+	cmd.Flags().BoolVarP(&force, "force", "f", false, "Purge blocks and sprites even when the code uses variables for them")
+	return cmd
+}
+
+// logCodeReferences logs a warning for each line of event code that can not be renumbered.
+func logCodeReferences(element, action string, references []mpagd.CodeReference) {
+	for _, reference := range references {
+		mpagd.LogMessage(element, fmt.Sprintf("%s line %d %s %s: %s", mpagd.GetEventNameByID(reference.Event), reference.Line, action, reference.Value, reference.Code), "warning", noColor)
+	}
+}
+
+// formatIDs formats a list of IDs for the log, or "none" when it is empty.
+func formatIDs(ids []int) string {
+	if len(ids) == 0 {
+		return "none"
+	}
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.Itoa(id)
+	}
+	return strings.Join(parts, ",")
+}
+
 // Cmd_CreateReadme creates a command to generate a README file for the project.
 func Cmd_CreateReadme() *cobra.Command {
 	return &cobra.Command{
@@ -509,8 +727,12 @@ func init() {
 	projectCmd.AddCommand(Cmd_LoadYAML())
 	projectCmd.AddCommand(Cmd_ImportAGD())
 	projectCmd.AddCommand(Cmd_ImportAGDSelective())
+	projectCmd.AddCommand(Cmd_ExportAGD())
 	projectCmd.AddCommand(Cmd_ListTemplates())
 	projectCmd.AddCommand(Cmd_CreateProjectFromTemplate())
 	projectCmd.AddCommand(Cmd_ProjectStats())
+	projectCmd.AddCommand(Cmd_DiffProjects())
+	projectCmd.AddCommand(Cmd_ValidateProject())
+	projectCmd.AddCommand(Cmd_UnusedProject())
 	projectCmd.AddCommand(Cmd_CreateReadme())
 }
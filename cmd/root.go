@@ -5,6 +5,7 @@ package cmd
 
 import (
 	"fmt"
+	"image"
 	"os"
 
 	"github.com/Mrpye/mpagd_util/mpagd"
@@ -26,6 +27,54 @@ func SetNoColor(no_color bool) {
 	noColor = no_color
 }
 
+// readProjectForUpdate reads the project file and creates a backup when it is updated in place.
+// Set withCode when the command can change the event code, so the code files are backed up too.
+func readProjectForUpdate(element, inFile, outFile string, withCode bool) (*mpagd.APJFile, error) {
+	apj := mpagd.NewAPJFile(inFile)
+	if err := apj.ReadAPJ(); err != nil {
+		return nil, fmt.Errorf("failed to read APJ file: %w", err)
+	}
+
+	// If the output file is the same as the input file, create a backup
+	if outFile == inFile {
+		if err := apj.BackupProjectFile(withCode); err != nil {
+			return nil, fmt.Errorf("failed to create backup: %w", err)
+		}
+		mpagd.LogMessage(element, fmt.Sprintf("Backup created: %s", inFile), "ok", noColor)
+	}
+	return apj, nil
+}
+
+// inlineImage holds the --inline and --scale flags shared by the render commands.
+type inlineImage struct {
+	protocol string
+	scale    int
+}
+
+// addInlineFlags adds the flags to show the rendered image inline with Sixel or Kitty graphics.
+func addInlineFlags(cmd *cobra.Command, inline *inlineImage) {
+	cmd.Flags().StringVarP(&inline.protocol, "inline", "i", "", "Show the image inline with terminal graphics: auto, kitty or sixel")
+	cmd.Flags().Lookup("inline").NoOptDefVal = string(mpagd.InlineAuto)
+	cmd.Flags().IntVar(&inline.scale, "scale", 2, "Size in screen pixels of each image pixel shown inline")
+}
+
+// enabled returns true when the image should be shown inline.
+func (inline *inlineImage) enabled() bool {
+	return inline.protocol != ""
+}
+
+// write shows the image inline with the protocol chosen by the --inline flag.
+func (inline *inlineImage) write(cmd *cobra.Command, img image.Image) error {
+	protocol, err := mpagd.ParseInlineProtocol(inline.protocol)
+	if err != nil {
+		return err
+	}
+	if err := mpagd.WriteInlineImage(cmd.OutOrStdout(), img, protocol, inline.scale); err != nil {
+		return fmt.Errorf("failed to write inline image: %w", err)
+	}
+	return nil
+}
+
 // GenerateDoc creates a new command to generate CLI documentation.
 func GenerateDoc() *cobra.Command {
 	var cmd = &cobra.Command{
@@ -2,7 +2,9 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 	"strconv"
+	"strings"
 
 	"github.com/Mrpye/mpagd_util/mpagd"
 	"github.com/spf13/cobra"
@@ -52,8 +54,8 @@ func Cmd_ImportScreens() *cobra.Command {
 
 			// Configure import options
 			options := mpagd.CreateImportOptions()
-			options.SetIgnoreOptions(true, true, true, true, true, true, false, true, true)
-			options.SetOwOptions(false, false, false, false, false, false, replaceExistingScreens, false, false)
+			options.SetIgnoreOptions(true, true, true, true, true, true, false, true, true, true)
+			options.SetOwOptions(false, false, false, false, false, false, replaceExistingScreens, false, false, false)
 
 			// Perform the import operation
 			if err := apj.ImportAGD(agdFilePath, options); err != nil {
@@ -71,13 +73,83 @@ func Cmd_ImportScreens() *cobra.Command {
 	return cmd
 }
 
+// Cmd_ImportScreenPNG creates a command to build a screen from a PNG image.
+func Cmd_ImportScreenPNG() *cobra.Command {
+	var blockTypeName string
+
+	var cmd = &cobra.Command{
+		Use:   "import-png [apj file] [png file] [[output file]]",
+		Short: "Build a screen from a PNG image.",
+		Long: `Slices a PNG image the size of the game window (window width*8 x window height*8) into 8x8 cells
+and appends a screen built from them. Cells with the same bitmap and attribute as an existing block reuse
+that block, preferring a block of the type given by --type. The other cells are added as new blocks of that type.`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Parse input arguments
+			apjFilePath := args[0]
+			pngFilePath := args[1]
+			outputFilePath := apjFilePath
+			if len(args) == 3 {
+				outputFilePath = args[2]
+			}
+
+			blockType, exists := mpagd.BlockTypeToId[strings.ToUpper(blockTypeName)]
+			if !exists {
+				return fmt.Errorf("invalid block type: %s", blockTypeName)
+			}
+
+			// Log the start of the import process
+			mpagd.LogMessage("Cmd_ImportScreenPNG", fmt.Sprintf("Starting import for file: %s", apjFilePath), "info", noColor)
+
+			// Read the APJ file
+			apj := mpagd.NewAPJFile(apjFilePath)
+			if err := apj.ReadAPJ(); err != nil {
+				return fmt.Errorf("failed to read APJ file: %w", err)
+			}
+
+			// Create a backup if the output file is the same as the input file
+			if outputFilePath == apjFilePath {
+				if err := apj.BackupProjectFile(false); err != nil {
+					return fmt.Errorf("failed to create backup: %w", err)
+				}
+				mpagd.LogMessage("Cmd_ImportScreenPNG", fmt.Sprintf("Backup created: %s", outputFilePath), "ok", noColor)
+			}
+
+			// Build the screen from the image
+			newBlocks, issues, err := apj.ImportScreenFromPNG(pngFilePath, blockType)
+			if err != nil {
+				return fmt.Errorf("failed to import screen from PNG file: %w", err)
+			}
+			for _, issue := range issues {
+				mpagd.LogMessage("Cmd_ImportScreenPNG", fmt.Sprintf("Cell at column %d, row %d %s", issue.Column, issue.Row, issue.Message), "warning", noColor)
+			}
+
+			// Write the updated APJ file
+			if err := apj.WriteAPJ(outputFilePath); err != nil {
+				return fmt.Errorf("failed to write updated APJ file: %w", err)
+			}
+
+			// Log the successful completion of the import
+			mpagd.LogMessage("Cmd_ImportScreenPNG", fmt.Sprintf("Screen %d imported successfully, %d new blocks were needed. Updated APJ file saved to %s", len(apj.Screens)-1, newBlocks, outputFilePath), "ok", noColor)
+			return nil
+		},
+	}
+
+	// Add a flag to set the type of the new blocks
+	cmd.Flags().StringVarP(&blockTypeName, "type", "t", "EMPTYBLOCK", "Block type for the new blocks")
+	return cmd
+}
+
 // Cmd_RenderScreensToBitmap creates a command to render a screen from an APJ file to a bitmap image.
 func Cmd_RenderScreensToBitmap() *cobra.Command {
+	var options mpagd.ScreenRenderOptions
 	var cmd = &cobra.Command{
 		Use:   "render-bmp [apj file] [screen id] [bitmap file]",
 		Short: "Render a screen from an APJ file to a bitmap image.",
-		Long:  `Renders a specific screen from an APJ file and saves it as a bitmap image in PNG format.`,
-		Args:  cobra.ExactArgs(3),
+		Long: `Renders a specific screen from an APJ file and saves it as a bitmap image in PNG format.
+With --sprites the first frame of each sprite placed on the screen is drawn in white, labelled with its sprite type,
+and with --objects the objects that start on the screen are drawn in their ink colour.`,
+		Args: cobra.ExactArgs(3),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Parse input arguments
 			apjFilePath := args[0]
@@ -100,7 +172,7 @@ func Cmd_RenderScreensToBitmap() *cobra.Command {
 			}
 
 			// Render the screen to a bitmap image
-			if err := apj.RenderScreenToBitmap(uint8(screenIndex), outputFilePath); err != nil {
+			if err := apj.RenderScreenToBitmap(uint8(screenIndex), outputFilePath, options); err != nil {
 				return fmt.Errorf("failed to render screen to bitmap: %w", err)
 			}
 
@@ -109,6 +181,56 @@ func Cmd_RenderScreensToBitmap() *cobra.Command {
 			return nil
 		},
 	}
+	cmd.Flags().BoolVarP(&options.Sprites, "sprites", "s", false, "Draw the sprites placed on the screen")
+	cmd.Flags().BoolVarP(&options.Objects, "objects", "o", false, "Draw the objects that start on the screen")
+	return cmd
+}
+
+// Cmd_RenderScreen creates a command to render a screen from an APJ file to the terminal.
+func Cmd_RenderScreen() *cobra.Command {
+	var options mpagd.ScreenRenderOptions
+	var inline inlineImage
+	var cmd = &cobra.Command{
+		Use:   "render [apj file] [screen id]",
+		Short: "Render a screen to the terminal.",
+		Long: `Renders a screen to the terminal in its Spectrum ink and paper colours, two rows of pixels per line.
+Use --mono, or set NO_COLOR, on terminals without 24-bit colour, the ink of each block is then drawn in the terminal's own colour and the paper is left clear.
+Use --inline to show the screen at full resolution on terminals with Sixel or Kitty graphics.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			apjFilePath := args[0]
+			screenIndex, err := strconv.Atoi(args[1])
+			if err != nil {
+				return fmt.Errorf("invalid screen ID: %w", err)
+			}
+
+			apj := mpagd.NewAPJFile(apjFilePath)
+			if err := apj.ReadAPJ(); err != nil {
+				return fmt.Errorf("failed to read APJ file: %w", err)
+			}
+			if screenIndex < 0 || screenIndex >= len(apj.Screens) {
+				return fmt.Errorf("screen out of range: %d", screenIndex)
+			}
+
+			mpagd.LogMessage("Cmd_RenderScreen", fmt.Sprintf("Rendering screen %d", screenIndex), "info", noColor)
+			if inline.enabled() {
+				img, err := apj.RenderScreenImage(uint8(screenIndex), options)
+				if err != nil {
+					return fmt.Errorf("failed to render screen: %w", err)
+				}
+				return inline.write(cmd, img)
+			}
+			colour := !options.Mono && os.Getenv("NO_COLOR") == ""
+			if err := apj.RenderScreenToTerminal(cmd.OutOrStdout(), uint8(screenIndex), options, colour); err != nil {
+				return fmt.Errorf("failed to render screen: %w", err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVarP(&options.Sprites, "sprites", "s", false, "Draw the sprites placed on the screen")
+	cmd.Flags().BoolVarP(&options.Objects, "objects", "o", false, "Draw the objects that start on the screen")
+	cmd.Flags().BoolVarP(&options.Mono, "mono", "m", false, "Render without colours, for terminals without colour support")
+	addInlineFlags(cmd, &inline)
 	return cmd
 }
 
@@ -166,10 +288,281 @@ func Cmd_ReorderScreens() *cobra.Command {
 	return cmd
 }
 
+// Cmd_DuplicateScreen creates a command to copy a screen in an APJ file.
+func Cmd_DuplicateScreen() *cobra.Command {
+	var index int
+	var copySprites bool
+	var cmd = &cobra.Command{
+		Use:   "duplicate [apj file] [screen] [[output file]]",
+		Short: "Copy a screen in an APJ file.",
+		Long: `Copy a screen to the end of the screens, or to the position given with --index.
+The map cells, sprite positions and object rooms of the screens after the copy are renumbered to match.`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			inFile := args[0]
+			outFile := inFile // Default to the input file if no output file is provided
+			if len(args) == 3 {
+				outFile = args[2]
+			}
+
+			screenIndex, err := strconv.Atoi(args[1])
+			if err != nil {
+				return fmt.Errorf("invalid screen index: %w", err)
+			}
+
+			apjFile, err := readProjectForUpdate("Cmd_DuplicateScreen", inFile, outFile, false)
+			if err != nil {
+				return err
+			}
+
+			newIndex, err := apjFile.DuplicateScreen(screenIndex, index, copySprites)
+			if err != nil {
+				return fmt.Errorf("failed to duplicate screen: %w", err)
+			}
+
+			if err := apjFile.WriteAPJ(outFile); err != nil {
+				return fmt.Errorf("failed to write APJ file: %w", err)
+			}
+
+			mpagd.LogMessage("Cmd_DuplicateScreen", fmt.Sprintf("Screen %d copied successfully to screen %d", screenIndex, newIndex), "ok", noColor)
+			return nil
+		},
+	}
+	cmd.Flags().IntVarP(&index, "index", "i", -1, "Position of the copy, the end of the screens by default")
+	cmd.Flags().BoolVarP(&copySprites, "sprites", "s", false, "Copy the sprite positions of the screen")
+	return cmd
+}
+
+// Cmd_InsertScreen creates a command to insert a blank screen into an APJ file.
+func Cmd_InsertScreen() *cobra.Command {
+	var block int
+	var cmd = &cobra.Command{
+		Use:   "insert [apj file] [index] [[output file]]",
+		Short: "Insert a blank screen into an APJ file.",
+		Long: `Insert a screen filled with a single block at the index, use the number of screens to add it to the end.
+The map cells, sprite positions and object rooms of the screens after it are renumbered to match.`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			inFile := args[0]
+			outFile := inFile // Default to the input file if no output file is provided
+			if len(args) == 3 {
+				outFile = args[2]
+			}
+
+			index, err := strconv.Atoi(args[1])
+			if err != nil {
+				return fmt.Errorf("invalid screen index: %w", err)
+			}
+			if block < 0 || block > 255 {
+				return fmt.Errorf("invalid block: %d", block)
+			}
+
+			apjFile, err := readProjectForUpdate("Cmd_InsertScreen", inFile, outFile, false)
+			if err != nil {
+				return err
+			}
+
+			if err := apjFile.InsertScreen(index, uint8(block)); err != nil {
+				return fmt.Errorf("failed to insert screen: %w", err)
+			}
+
+			if err := apjFile.WriteAPJ(outFile); err != nil {
+				return fmt.Errorf("failed to write APJ file: %w", err)
+			}
+
+			mpagd.LogMessage("Cmd_InsertScreen", fmt.Sprintf("Screen %d inserted successfully", index), "ok", noColor)
+			return nil
+		},
+	}
+	cmd.Flags().IntVarP(&block, "block", "b", 0, "Block used to fill the new screen")
+	return cmd
+}
+
+// Cmd_DeleteScreens creates a command to delete screens from an APJ file.
+func Cmd_DeleteScreens() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "delete [apj file] [screen ids] [[output file]]",
+		Short: "Delete screens from an APJ file.",
+		Long: `Delete screens from an APJ file, e.g. "3,7-9". The map cells of a deleted screen become empty (255),
+its sprite positions are removed, its objects are moved to room 254 (not on any screen), and the screens after it
+are renumbered. The start screen can not be deleted.`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			inFile := args[0]
+			outFile := inFile // Default to the input file if no output file is provided
+			if len(args) == 3 {
+				outFile = args[2]
+			}
+
+			ids, err := mpagd.ParseIntList(args[1])
+			if err != nil {
+				return fmt.Errorf("invalid screen ids: %w", err)
+			}
+
+			apjFile, err := readProjectForUpdate("Cmd_DeleteScreens", inFile, outFile, false)
+			if err != nil {
+				return err
+			}
+
+			removed, moved, err := apjFile.DeleteScreens(ids)
+			if err != nil {
+				return fmt.Errorf("failed to delete screens: %w", err)
+			}
+			if removed > 0 {
+				mpagd.LogMessage("Cmd_DeleteScreens", fmt.Sprintf("%d sprite positions removed", removed), "warning", noColor)
+			}
+			if moved > 0 {
+				mpagd.LogMessage("Cmd_DeleteScreens", fmt.Sprintf("%d objects moved to room %d", moved, mpagd.ObjectNoRoom), "warning", noColor)
+			}
+
+			if err := apjFile.WriteAPJ(outFile); err != nil {
+				return fmt.Errorf("failed to write APJ file: %w", err)
+			}
+
+			mpagd.LogMessage("Cmd_DeleteScreens", fmt.Sprintf("%d screens deleted successfully, %d screens left", len(ids), apjFile.NrOfScreens), "ok", noColor)
+			return nil
+		},
+	}
+	return cmd
+}
+
+// Cmd_ReplaceBlock creates a command to replace a block across the screens of an APJ file.
+func Cmd_ReplaceBlock() *cobra.Command {
+	var screensSpec string
+	var regionSpec string
+	var cmd = &cobra.Command{
+		Use:   "replace-block [apj file] [from block] [to block] [[output file]]",
+		Short: "Replace a block across the screens of an APJ file.",
+		Long: `Replace every cell using the from block with the to block. Use --screens to limit the screens, e.g. "0-5,9",
+and --region to limit the cells to a rectangle given as "x,y,width,height".`,
+		Args: cobra.RangeArgs(3, 4),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			inFile := args[0]
+			outFile := inFile // Default to the input file if no output file is provided
+			if len(args) == 4 {
+				outFile = args[3]
+			}
+
+			from, err := strconv.ParseUint(args[1], 10, 8)
+			if err != nil {
+				return fmt.Errorf("invalid from block: %w", err)
+			}
+			to, err := strconv.ParseUint(args[2], 10, 8)
+			if err != nil {
+				return fmt.Errorf("invalid to block: %w", err)
+			}
+			screens, err := parseScreenList(screensSpec)
+			if err != nil {
+				return err
+			}
+			var region *mpagd.ScreenRegion
+			if regionSpec != "" {
+				if region, err = mpagd.ParseScreenRegion(regionSpec); err != nil {
+					return err
+				}
+			}
+
+			apjFile, err := readProjectForUpdate("Cmd_ReplaceBlock", inFile, outFile, false)
+			if err != nil {
+				return err
+			}
+
+			replaced, err := apjFile.ReplaceBlock(uint8(from), uint8(to), screens, region)
+			if err != nil {
+				return fmt.Errorf("failed to replace block: %w", err)
+			}
+
+			if err := apjFile.WriteAPJ(outFile); err != nil {
+				return fmt.Errorf("failed to write APJ file: %w", err)
+			}
+
+			mpagd.LogMessage("Cmd_ReplaceBlock", fmt.Sprintf("Block %d replaced with block %d in %d cells", from, to, replaced), "ok", noColor)
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&screensSpec, "screens", "s", "", "Screens to change, e.g. \"0-5,9\", all screens by default")
+	cmd.Flags().StringVarP(&regionSpec, "region", "r", "", "Cells to change as \"x,y,width,height\", the whole screen by default")
+	return cmd
+}
+
+// Cmd_FindBlock creates a command to list the screen cells using a block or block type.
+func Cmd_FindBlock() *cobra.Command {
+	var screensSpec string
+	var cmd = &cobra.Command{
+		Use:   "find-block [apj file] [block or block type]",
+		Short: "List the screen cells using a block or block type.",
+		Long: `List every screen cell using a block, given as a block number, or any block of a type, given as a
+block type name such as WALLBLOCK. The cells are listed as x,y with a summary for each screen.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			screens, err := parseScreenList(screensSpec)
+			if err != nil {
+				return err
+			}
+
+			apjFile := mpagd.NewAPJFile(args[0])
+			if err := apjFile.ReadAPJ(); err != nil {
+				return fmt.Errorf("failed to read APJ file: %w", err)
+			}
+
+			var blocks []int
+			if block, err := strconv.Atoi(args[1]); err == nil {
+				blocks = []int{block}
+			} else if blockType, exists := mpagd.BlockTypeToId[strings.ToUpper(args[1])]; exists {
+				blocks = apjFile.BlocksOfType(blockType)
+			} else {
+				return fmt.Errorf("invalid block or block type: %s", args[1])
+			}
+
+			locations, err := apjFile.FindBlocks(blocks, screens)
+			if err != nil {
+				return fmt.Errorf("failed to find block: %w", err)
+			}
+
+			// Group the cells by screen for the summary
+			cells := make(map[int][]string)
+			var order []int
+			for _, location := range locations {
+				if _, exists := cells[location.Screen]; !exists {
+					order = append(order, location.Screen)
+				}
+				cells[location.Screen] = append(cells[location.Screen], fmt.Sprintf("%d,%d", location.X, location.Y))
+			}
+			for _, screen := range order {
+				mpagd.LogMessage("Cmd_FindBlock", fmt.Sprintf("Screen %d: %d cells at %s", screen, len(cells[screen]), strings.Join(cells[screen], " ")), "info", noColor)
+			}
+
+			mpagd.LogMessage("Cmd_FindBlock", fmt.Sprintf("%s found in %d cells on %d screens", args[1], len(locations), len(order)), "ok", noColor)
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&screensSpec, "screens", "s", "", "Screens to search, e.g. \"0-5,9\", all screens by default")
+	return cmd
+}
+
+// parseScreenList parses a list of screens, an empty list means every screen.
+func parseScreenList(spec string) ([]int, error) {
+	if spec == "" {
+		return nil, nil
+	}
+	screens, err := mpagd.ParseIntList(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid screens: %w", err)
+	}
+	return screens, nil
+}
+
 // Initialize the screens command and its subcommands
 func init() {
 	RootCmd.AddCommand(screensCmd)
 	screensCmd.AddCommand(Cmd_ImportScreens())
+	screensCmd.AddCommand(Cmd_ImportScreenPNG())
 	screensCmd.AddCommand(Cmd_RenderScreensToBitmap())
+	screensCmd.AddCommand(Cmd_RenderScreen())
 	screensCmd.AddCommand(Cmd_ReorderScreens())
+	screensCmd.AddCommand(Cmd_DuplicateScreen())
+	screensCmd.AddCommand(Cmd_InsertScreen())
+	screensCmd.AddCommand(Cmd_DeleteScreens())
+	screensCmd.AddCommand(Cmd_ReplaceBlock())
+	screensCmd.AddCommand(Cmd_FindBlock())
 }
@@ -20,6 +20,18 @@ var spriteRotateCmd = &cobra.Command{
 	Long:  `Rotate sprite in the MPAGD project.`,
 }
 
+var spriteFlipCmd = &cobra.Command{
+	Use:   "flip",
+	Short: "Flip sprite in the MPAGD project.",
+	Long:  `Flip sprite in the MPAGD project.`,
+}
+
+var spriteFramesCmd = &cobra.Command{
+	Use:   "frames",
+	Short: "Manage the frames of a sprite in the MPAGD project.",
+	Long:  `Add, copy, remove and reorder the animation frames of a sprite in the MPAGD project.`,
+}
+
 func Cmd_ImportSprites() *cobra.Command {
 	var replace bool
 	var cmd = &cobra.Command{
@@ -51,8 +63,8 @@ func Cmd_ImportSprites() *cobra.Command {
 			}
 
 			options := mpagd.CreateImportOptions()
-			options.SetIgnoreOptions(true, true, true, true, false, true, true, true, true)
-			options.SetOwOptions(false, false, false, false, replace, false, false, false, false)
+			options.SetIgnoreOptions(true, true, true, true, false, true, true, true, true, true)
+			options.SetOwOptions(false, false, false, false, replace, false, false, false, false, false)
 
 			if err := apj.ImportAGD(agdFilePath, options); err != nil {
 				return fmt.Errorf("failed to import Sprites from AGD file: %w", err)
@@ -66,11 +78,70 @@ func Cmd_ImportSprites() *cobra.Command {
 	return cmd
 }
 
+// Cmd_ImportSpritesPNG creates a command to import sprites from a PNG sprite sheet.
+func Cmd_ImportSpritesPNG() *cobra.Command {
+	var replace bool
+	var frames int
+	var gap int
+	var cmd = &cobra.Command{
+		Use:   "import-png [apj file] [png file or directory] [[output file]]",
+		Short: "Import sprites from a PNG sprite sheet into an APJ file.",
+		Long: `Reads 16x16 cells from a PNG file and turns them into sprite frames.
+By default all the cells of a file are the frames of one sprite, use --frames to group every N
+consecutive cells into one sprite instead. Black and transparent pixels are clear, any other colour is set.
+When a directory is given, its PNG files are read in the order of the number in their name,
+so the files written by sprites render-bmp --seprate can be imported again.
+Use --frames 1 --gap 1 to import a sheet written by sprites render-bmp.`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			apjFilePath := args[0]
+			pngPath := args[1]
+			outputFilePath := apjFilePath
+			if len(args) == 3 {
+				outputFilePath = args[2]
+			}
+
+			mpagd.LogMessage("Cmd_ImportSpritesPNG", fmt.Sprintf("Starting import for file: %s", apjFilePath), "info", noColor)
+
+			apj := mpagd.NewAPJFile(apjFilePath)
+			apj.SetNoColorOutput(noColor)
+			if err := apj.ReadAPJ(); err != nil {
+				return fmt.Errorf("failed to read APJ file: %w", err)
+			}
+
+			if outputFilePath == apjFilePath {
+				err := apj.BackupProjectFile(false)
+				if err != nil {
+					return fmt.Errorf("failed to create backup: %w", err)
+				}
+				mpagd.LogMessage("Cmd_ImportSpritesPNG", fmt.Sprintf("Backup created: %s", outputFilePath), "ok", noColor)
+			}
+
+			count, err := apj.ImportSpritesFromPNG(pngPath, frames, replace, gap)
+			if err != nil {
+				return fmt.Errorf("failed to import sprites from PNG file: %w", err)
+			}
+
+			if err := apj.WriteAPJ(outputFilePath); err != nil {
+				return fmt.Errorf("failed to write updated APJ file: %w", err)
+			}
+
+			mpagd.LogMessage("Cmd_ImportSpritesPNG", fmt.Sprintf("%d sprites imported successfully. Updated APJ file saved to %s", count, outputFilePath), "ok", noColor)
+			return nil
+		},
+	}
+	cmd.Flags().BoolVarP(&replace, "replace", "r", false, "Replace the current Sprites")
+	cmd.Flags().IntVarP(&frames, "frames", "f", 0, "Number of cells per sprite, 0 for all the cells of each file")
+	cmd.Flags().IntVarP(&gap, "gap", "g", 0, "Number of pixels between the cells")
+	return cmd
+}
+
 func Cmd_RotateSpritesCCW90() *cobra.Command {
 	var repeat int
 	var startRange uint8
 	var endRange uint8
 	var add bool
+	var frames bool
 	var cmd = &cobra.Command{
 		Use:   "ccw [project file] [sprite number] [[output file]]",
 		Short: "Rotate a sprite 90 degrees counterclockwise.",
@@ -78,6 +149,9 @@ func Cmd_RotateSpritesCCW90() *cobra.Command {
 		Args:  cobra.MinimumNArgs(2), // Ensure at least 2 arguments are provided
 		RunE: func(cmd *cobra.Command, args []string) error {
 			inFile := args[0]
+			if add && frames {
+				return fmt.Errorf("--add and --frames can not be used together")
+			}
 			spriteNumber, err := strconv.Atoi(args[1])
 			if err != nil {
 				return fmt.Errorf("invalid sprite number: %w", err)
@@ -106,7 +180,17 @@ func Cmd_RotateSpritesCCW90() *cobra.Command {
 
 			// Rotate sprites
 			currentSprite := spriteNumber
-			if startRange == 0 && endRange == 0 {
+			if frames {
+				firstSprite, lastSprite := spriteNumber, spriteNumber+1
+				if startRange != 0 || endRange != 0 {
+					firstSprite, lastSprite = int(startRange), int(endRange)
+				}
+				for r := firstSprite; r < lastSprite; r++ {
+					if err := apjFile.RotateSpriteToFrames(uint8(r), true, repeat); err != nil {
+						return fmt.Errorf("failed to rotate sprite: %w", err)
+					}
+				}
+			} else if startRange == 0 && endRange == 0 {
 				for i := 0; i < repeat; i++ {
 					processedSprite, err := apjFile.RotateSprite(uint8(currentSprite), true, add)
 					if err != nil {
@@ -143,6 +227,7 @@ func Cmd_RotateSpritesCCW90() *cobra.Command {
 	}
 	cmd.Flags().IntVarP(&repeat, "repeat", "r", 1, "Repeat the operation this many times")
 	cmd.Flags().BoolVarP(&add, "add", "a", false, "Add each rotated sprite to the project file")
+	cmd.Flags().BoolVarP(&frames, "frames", "f", false, "Add each rotation as extra frames of the sprite")
 	cmd.Flags().Uint8VarP(&startRange, "start", "s", 0, "Sprite Start range for the operation")
 	cmd.Flags().Uint8VarP(&endRange, "end", "e", 0, "Sprite End range for the operation")
 	return cmd
@@ -153,6 +238,7 @@ func Cmd_RotateSpritesCW90() *cobra.Command {
 	var startRange uint8
 	var endRange uint8
 	var add bool
+	var frames bool
 	var cmd = &cobra.Command{
 		Use:   "cw [project file] [sprite number] [[output file]]",
 		Short: "Rotate a sprite 90 degrees clockwise.",
@@ -160,6 +246,9 @@ func Cmd_RotateSpritesCW90() *cobra.Command {
 		Args:  cobra.MinimumNArgs(2), // Ensure at least 2 arguments are provided
 		RunE: func(cmd *cobra.Command, args []string) error {
 			inFile := args[0]
+			if add && frames {
+				return fmt.Errorf("--add and --frames can not be used together")
+			}
 			spriteNumber, err := strconv.Atoi(args[1])
 			if err != nil {
 				return fmt.Errorf("invalid sprite number: %w", err)
@@ -188,7 +277,17 @@ func Cmd_RotateSpritesCW90() *cobra.Command {
 
 			// Rotate sprites
 			currentSprite := spriteNumber
-			if startRange == 0 && endRange == 0 {
+			if frames {
+				firstSprite, lastSprite := spriteNumber, spriteNumber+1
+				if startRange != 0 || endRange != 0 {
+					firstSprite, lastSprite = int(startRange), int(endRange)
+				}
+				for r := firstSprite; r < lastSprite; r++ {
+					if err := apjFile.RotateSpriteToFrames(uint8(r), false, repeat); err != nil {
+						return fmt.Errorf("failed to rotate sprite: %w", err)
+					}
+				}
+			} else if startRange == 0 && endRange == 0 {
 				for i := 0; i < repeat; i++ {
 					processedSprite, err := apjFile.RotateSprite(uint8(currentSprite), false, add)
 					if add {
@@ -225,6 +324,83 @@ func Cmd_RotateSpritesCW90() *cobra.Command {
 	}
 	cmd.Flags().IntVarP(&repeat, "repeat", "r", 1, "Repeat the operation this many times")
 	cmd.Flags().BoolVarP(&add, "add", "a", false, "Add each rotated sprite to the project file")
+	cmd.Flags().BoolVarP(&frames, "frames", "f", false, "Add each rotation as extra frames of the sprite")
+	cmd.Flags().Uint8VarP(&startRange, "start", "s", 0, "Sprite Start range for the operation")
+	cmd.Flags().Uint8VarP(&endRange, "end", "e", 0, "Sprite End range for the operation")
+	return cmd
+}
+
+// Cmd_FlipSprite creates a command to mirror every frame of a sprite horizontally or vertically.
+func Cmd_FlipSprite(vertical bool) *cobra.Command {
+	var repeat int
+	var startRange uint8
+	var endRange uint8
+	var add bool
+	name, direction := flipDirection(vertical)
+
+	var cmd = &cobra.Command{
+		Use:   name + " [project file] [sprite number] [[output file]]",
+		Short: "Flip a sprite " + direction + ".",
+		Long:  `Flip every frame of a sprite ` + direction + `.`,
+		Args:  cobra.MinimumNArgs(2), // Ensure at least 2 arguments are provided
+		RunE: func(cmd *cobra.Command, args []string) error {
+			inFile := args[0]
+			spriteNumber, err := strconv.Atoi(args[1])
+			if err != nil {
+				return fmt.Errorf("invalid sprite number: %w", err)
+			}
+			outFile := inFile // Default to the input file if no output file is provided
+			if len(args) == 3 {
+				outFile = args[2]
+			}
+
+			mpagd.LogMessage("Cmd_FlipSprite", fmt.Sprintf("Starting flip for file: %s", inFile), "ok", noColor)
+
+			apjFile := mpagd.NewAPJFile(inFile)
+			err = apjFile.ReadAPJ()
+			if err != nil {
+				return fmt.Errorf("failed to read APJ file: %w", err)
+			}
+
+			// If the output file is the same as the input file, create a backup
+			if outFile == inFile {
+				err := apjFile.BackupProjectFile(false)
+				if err != nil {
+					return fmt.Errorf("failed to create backup: %w", err)
+				}
+				mpagd.LogMessage("Cmd_FlipSprite", fmt.Sprintf("Backup created: %s", inFile), "ok", noColor)
+			}
+
+			// Flip sprites
+			firstSprite, lastSprite := spriteNumber, spriteNumber+1
+			if startRange != 0 || endRange != 0 {
+				firstSprite, lastSprite = int(startRange), int(endRange)
+			}
+			for r := firstSprite; r < lastSprite; r++ {
+				currentSprite := r
+				for i := 0; i < repeat; i++ {
+					processedSprite, err := apjFile.FlipSprite(uint8(currentSprite), vertical, add)
+					if err != nil {
+						return fmt.Errorf("failed to flip sprite: %w", err)
+					}
+					if add {
+						currentSprite = int(processedSprite)
+					}
+				}
+			}
+
+			// Write the updated APJ file
+			err = apjFile.WriteAPJ(outFile)
+			if err != nil {
+				return fmt.Errorf("failed to write APJ file: %w", err)
+			}
+
+			mpagd.LogMessage("Cmd_FlipSprite", fmt.Sprintf("Flip completed for sprite number %d", spriteNumber), "ok", noColor)
+			return nil
+		},
+	}
+	cmd.Flags().IntVarP(&repeat, "repeat", "r", 1, "Repeat the operation this many times")
+	cmd.Flags().BoolVarP(&add, "add", "a", false, "Add each flipped sprite to the project file")
 	cmd.Flags().Uint8VarP(&startRange, "start", "s", 0, "Sprite Start range for the operation")
 	cmd.Flags().Uint8VarP(&endRange, "end", "e", 0, "Sprite End range for the operation")
 	return cmd
@@ -232,12 +408,16 @@ func Cmd_RotateSpritesCW90() *cobra.Command {
 
 func Cmd_RenderSprite() *cobra.Command {
 	var frame uint8
+	var allFrames bool
 	var reorderStr string
 	var offset int
+	var inline inlineImage
 	var cmd = &cobra.Command{
 		Use:   "render [project file]  [[start block]] [[end block]]",
 		Short: "Render a sprite to the terminal.",
-		Long:  `Render a specific sprite from the project file to the terminal for visualization.`,
+		Long: `Render a specific sprite from the project file to the terminal for visualization.
+Use --frame to choose the animation frame, or --all-frames to show the frames of each sprite side by side.
+Use --inline to show the sprites at full resolution on terminals with Sixel or Kitty graphics.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			projectFile := args[0]
 			startIndex := 0
@@ -272,17 +452,28 @@ func Cmd_RenderSprite() *cobra.Command {
 				return fmt.Errorf("invalid number of arguments")
 			}
 
-			if startIndex < 0 || startIndex >= len(apjFile.Blocks) {
+			if startIndex < 0 || startIndex >= len(apjFile.Sprites) {
 				return fmt.Errorf("start Sprite out of range: %d", startIndex)
 			}
-			if endIndex < 0 || endIndex > len(apjFile.Blocks) {
+			if endIndex < 0 || endIndex > len(apjFile.Sprites) {
 				return fmt.Errorf("end Sprite out of range: %d", endIndex)
 			}
 			var reorder []int
 			if reorderStr != "" {
 				reorder = mpagd.CSVToIntSlice(reorderStr)
 			}
-			if err := apjFile.RenderSpriteToTerminal(uint8(startIndex), uint8(endIndex), reorder, offset); err != nil {
+			renderFrame := int(frame)
+			if allFrames {
+				renderFrame = mpagd.AllFrames
+			}
+			if inline.enabled() {
+				img, err := apjFile.RenderSpriteImage(uint8(startIndex), uint8(endIndex), reorder, offset, renderFrame)
+				if err != nil {
+					return fmt.Errorf("failed to render sprite: %w", err)
+				}
+				return inline.write(cmd, img)
+			}
+			if err := apjFile.RenderSpriteToTerminal(uint8(startIndex), uint8(endIndex), reorder, offset, renderFrame); err != nil {
 				return fmt.Errorf("failed to render sprite: %w", err)
 			}
 
@@ -291,20 +482,25 @@ func Cmd_RenderSprite() *cobra.Command {
 	}
 	cmd.Flags().StringVarP(&reorderStr, "reorder", "r", "", "Reorder the blocks in the output file")
 	cmd.Flags().Uint8VarP(&frame, "frame", "f", 0, "Sprite frame to render")
+	cmd.Flags().BoolVarP(&allFrames, "all-frames", "a", false, "Render all the frames of each sprite side by side")
 	cmd.Flags().IntVarP(&offset, "offset", "o", 0, "Offset for the start of the reordering blocks")
+	addInlineFlags(cmd, &inline)
 	return cmd
 }
 
 func Cmd_RenderSpriteToBitmap() *cobra.Command {
 	var frame uint8
+	var allFrames bool
 	var reorderStr string
 	var offset int
 	var seperate bool
 	var cmd = &cobra.Command{
 		Use:   "render-bmp [project file] [[start block]] [[end block]] [output file]",
 		Short: "Render sprites to a bitmap file.",
-		Long:  `Render specific sprites from the project file to a bitmap file for visualization.`,
-		Args:  cobra.MinimumNArgs(2), // Ensure at least 2 arguments are provided
+		Long: `Render specific sprites from the project file to a bitmap file for visualization.
+Use --frame to choose the animation frame, or --all-frames to render the frames of each sprite side by side.
+With --seprate each sprite is written to its own file with all its frames side by side.`,
+		Args: cobra.MinimumNArgs(2), // Ensure at least 2 arguments are provided
 		RunE: func(cmd *cobra.Command, args []string) error {
 			projectFile := args[0]
 			outputFile := args[len(args)-1]
@@ -359,7 +555,11 @@ func Cmd_RenderSpriteToBitmap() *cobra.Command {
 					return fmt.Errorf("failed to render sprite to separate bitmaps: %w", err)
 				}
 			} else {
-				if err := apjFile.RenderSpriteToBitmap(uint8(startIndex), uint8(endIndex), outputFile, reorder, offset); err != nil {
+				renderFrame := int(frame)
+				if allFrames {
+					renderFrame = mpagd.AllFrames
+				}
+				if err := apjFile.RenderSpriteToBitmap(uint8(startIndex), uint8(endIndex), outputFile, reorder, offset, renderFrame); err != nil {
 					return fmt.Errorf("failed to render sprite to bitmap: %w", err)
 				}
 			}
@@ -372,10 +572,85 @@ func Cmd_RenderSpriteToBitmap() *cobra.Command {
 	cmd.Flags().StringVarP(&reorderStr, "reorder", "r", "", "Reorder the blocks in the output file")
 	cmd.Flags().BoolVarP(&seperate, "seprate", "s", false, "Render to seperate files")
 	cmd.Flags().Uint8VarP(&frame, "frame", "f", 0, "Sprite frame to render")
+	cmd.Flags().BoolVarP(&allFrames, "all-frames", "a", false, "Render all the frames of each sprite side by side")
 	cmd.Flags().IntVarP(&offset, "offset", "o", 0, "Offset for the start of the reordering blocks")
 	return cmd
 }
 
+// Cmd_RenderSpriteToGIF creates a command to write an animated GIF for each sprite.
+func Cmd_RenderSpriteToGIF() *cobra.Command {
+	var reorderStr string
+	var offset int
+	var delay int
+	var cmd = &cobra.Command{
+		Use:   "render-gif [project file] [[start sprite]] [[end sprite]] [output directory]",
+		Short: "Render sprite animations to GIF files.",
+		Long:  `Write an animated GIF of each sprite to the output directory, named sprite_N.gif, showing all its frames.`,
+		Args:  cobra.MinimumNArgs(2), // Ensure at least 2 arguments are provided
+		RunE: func(cmd *cobra.Command, args []string) error {
+			projectFile := args[0]
+			outputDir := args[len(args)-1]
+			startIndex := 0
+			endIndex := 0
+
+			apjFile := mpagd.NewAPJFile(projectFile)
+			if err := apjFile.ReadAPJ(); err != nil {
+				return fmt.Errorf("failed to read project file: %w", err)
+			}
+
+			if len(args) == 2 {
+				startIndex = 0
+				endIndex = len(apjFile.Sprites)
+			} else if len(args) == 3 {
+				spriteNumber, err := strconv.Atoi(args[1])
+				if err != nil {
+					return fmt.Errorf("invalid sprite number: %w", err)
+				}
+				startIndex = spriteNumber
+				endIndex = spriteNumber + 1
+			} else if len(args) == 4 {
+				spriteNumber, err := strconv.Atoi(args[1])
+				if err != nil {
+					return fmt.Errorf("invalid sprite number: %w", err)
+				}
+				spriteNumber2, err := strconv.Atoi(args[2])
+				if err != nil {
+					return fmt.Errorf("invalid sprite number: %w", err)
+				}
+				startIndex = spriteNumber
+				endIndex = spriteNumber2
+			} else {
+				return fmt.Errorf("invalid number of arguments")
+			}
+
+			if startIndex < 0 || startIndex >= len(apjFile.Sprites) {
+				return fmt.Errorf("start sprite out of range: %d", startIndex)
+			}
+			if endIndex < 0 || endIndex > len(apjFile.Sprites) {
+				return fmt.Errorf("end sprite out of range: %d", endIndex)
+			}
+			if delay < 0 {
+				return fmt.Errorf("invalid frame delay: %d", delay)
+			}
+
+			var reorder []int
+			if reorderStr != "" {
+				reorder = mpagd.CSVToIntSlice(reorderStr)
+			}
+			if err := apjFile.RenderSpriteToGIF(uint8(startIndex), uint8(endIndex), outputDir, reorder, offset, delay); err != nil {
+				return fmt.Errorf("failed to render sprite to GIF: %w", err)
+			}
+
+			fmt.Printf("Sprites %d to %d successfully rendered to %s\n", startIndex, endIndex-1, outputDir)
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&reorderStr, "reorder", "r", "", "Reorder the sprites in the output files")
+	cmd.Flags().IntVarP(&delay, "delay", "d", 10, "Time each frame is shown in 100ths of a second")
+	cmd.Flags().IntVarP(&offset, "offset", "o", 0, "Offset for the start of the reordering sprites")
+	return cmd
+}
+
 // Cmd_ReorderSprites creates a command to reorder sprites in the MPAGD project.
 func Cmd_ReorderSprites() *cobra.Command {
 	var offset int
@@ -432,13 +707,522 @@ func Cmd_ReorderSprites() *cobra.Command {
 	return cmd
 }
 
+// Cmd_DeleteSprites creates a command to delete sprites from the MPAGD project.
+func Cmd_DeleteSprites() *cobra.Command {
+	var removePositions bool
+	var force bool
+	var cmd = &cobra.Command{
+		Use:   "delete [project file] [sprite ids] [[output file]]",
+		Short: "Delete sprites from the MPAGD project.",
+		Long: `Delete sprites from the MPAGD project, e.g. "3,7-9". The sprite positions and the IMAGE and SPAWN
+statements in the event code using the sprites after a deleted sprite are renumbered to match.
+Sprites that are still placed on a screen are not deleted unless --remove-positions is used, which
+removes their sprite positions as well. Sprites are not deleted when the code uses a deleted sprite,
+a variable, or a constant such as UP for a sprite that moves, unless --force is used.`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			inFile := args[0]
+			outFile := inFile // Default to the input file if no output file is provided
+			if len(args) == 3 {
+				outFile = args[2]
+			}
+
+			ids, err := mpagd.ParseIntList(args[1])
+			if err != nil {
+				return fmt.Errorf("invalid sprite ids: %w", err)
+			}
+
+			apjFile := mpagd.NewAPJFile(inFile)
+			if err := apjFile.ReadAPJ(); err != nil {
+				return fmt.Errorf("failed to read APJ file: %w", err)
+			}
+
+			// Lines of code that can not be renumbered would use the wrong sprite after the delete
+			unmapped, err := apjFile.FindImageReferences(apjFile.DeleteSpritesMapping(ids))
+			if err != nil {
+				return fmt.Errorf("failed to read the event code: %w", err)
+			}
+			logCodeReferences("Cmd_DeleteSprites", "uses the image", unmapped)
+			if len(unmapped) > 0 && !force {
+				mpagd.LogMessage("Cmd_DeleteSprites", "Sprites not deleted as the code uses images that can not be renumbered, use --force to delete them", "warning", noColor)
+				return nil
+			}
+
+			// If the output file is the same as the input file, create a backup including the code files
+			if outFile == inFile {
+				if err := apjFile.BackupProjectFile(true); err != nil {
+					return fmt.Errorf("failed to create backup: %w", err)
+				}
+				mpagd.LogMessage("Cmd_DeleteSprites", fmt.Sprintf("Backup created: %s", inFile), "ok", noColor)
+			}
+
+			removed, err := apjFile.DeleteSprites(ids, removePositions, outFile)
+			if err != nil {
+				return fmt.Errorf("failed to delete sprites: %w", err)
+			}
+			if removed > 0 {
+				mpagd.LogMessage("Cmd_DeleteSprites", fmt.Sprintf("%d sprite positions removed", removed), "warning", noColor)
+			}
+
+			if err := apjFile.WriteAPJ(outFile); err != nil {
+				return fmt.Errorf("failed to write APJ file: %w", err)
+			}
+
+			mpagd.LogMessage("Cmd_DeleteSprites", fmt.Sprintf("%d sprites deleted successfully, %d sprites left", len(ids), apjFile.NrOfSprites), "ok", noColor)
+			return nil
+		},
+	}
+	cmd.Flags().BoolVarP(&removePositions, "remove-positions", "r", false, "Remove the sprite positions using the deleted sprites")
+	cmd.Flags().BoolVarP(&force, "force", "f", false, "Delete sprites even when the code uses images that can not be renumbered")
+	return cmd
+}
+
+// Cmd_InsertSprite creates a command to insert a blank sprite into the MPAGD project.
+func Cmd_InsertSprite() *cobra.Command {
+	var frames int
+	var force bool
+	var cmd = &cobra.Command{
+		Use:   "insert [project file] [index] [[output file]]",
+		Short: "Insert a blank sprite into the MPAGD project.",
+		Long: `Insert a blank sprite at the index, use the number of sprites to add it to the end.
+The sprites from the index onwards move up by one, and the sprite positions and the IMAGE and SPAWN
+statements in the event code are renumbered to match. The sprite is not inserted when the code uses
+a variable or a constant such as UP for a sprite that moves, unless --force is used.`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			inFile := args[0]
+			outFile := inFile // Default to the input file if no output file is provided
+			if len(args) == 3 {
+				outFile = args[2]
+			}
+
+			index, err := strconv.Atoi(args[1])
+			if err != nil {
+				return fmt.Errorf("invalid sprite index: %w", err)
+			}
+
+			apjFile := mpagd.NewAPJFile(inFile)
+			if err := apjFile.ReadAPJ(); err != nil {
+				return fmt.Errorf("failed to read APJ file: %w", err)
+			}
+
+			// Lines of code that can not be renumbered would use the wrong sprite after the insert
+			unmapped, err := apjFile.FindImageReferences(apjFile.InsertSpriteMapping(index))
+			if err != nil {
+				return fmt.Errorf("failed to read the event code: %w", err)
+			}
+			logCodeReferences("Cmd_InsertSprite", "uses the image", unmapped)
+			if len(unmapped) > 0 && !force {
+				mpagd.LogMessage("Cmd_InsertSprite", "Sprite not inserted as the code uses images that can not be renumbered, use --force to insert it", "warning", noColor)
+				return nil
+			}
+
+			// If the output file is the same as the input file, create a backup including the code files
+			if outFile == inFile {
+				if err := apjFile.BackupProjectFile(true); err != nil {
+					return fmt.Errorf("failed to create backup: %w", err)
+				}
+				mpagd.LogMessage("Cmd_InsertSprite", fmt.Sprintf("Backup created: %s", inFile), "ok", noColor)
+			}
+
+			if err := apjFile.InsertSprite(index, frames, outFile); err != nil {
+				return fmt.Errorf("failed to insert sprite: %w", err)
+			}
+
+			if err := apjFile.WriteAPJ(outFile); err != nil {
+				return fmt.Errorf("failed to write APJ file: %w", err)
+			}
+
+			mpagd.LogMessage("Cmd_InsertSprite", fmt.Sprintf("Sprite %d inserted successfully with %d frames", index, frames), "ok", noColor)
+			return nil
+		},
+	}
+	cmd.Flags().IntVarP(&frames, "frames", "f", 1, "Number of frames in the new sprite")
+	cmd.Flags().BoolVar(&force, "force", false, "Insert the sprite even when the code uses images that can not be renumbered")
+	return cmd
+}
+
+// Cmd_DedupeSprites creates a command to merge identical sprites in the MPAGD project.
+func Cmd_DedupeSprites() *cobra.Command {
+	var dryRun bool
+	var spectrumOnly bool
+	var force bool
+	var cmd = &cobra.Command{
+		Use:   "dedupe [project file] [[output file]]",
+		Short: "Merge identical sprites in the MPAGD project.",
+		Long: `Find sprites with the same frames on every platform and merge each one into the first sprite it matches.
+The sprite positions and the IMAGE and SPAWN statements in the event code are changed to use the sprite that is kept.
+Use --spectrum-only to compare only the Spectrum frames, and --dry-run to list the sprites that would be merged.
+Sprites are not merged when the code sets IMAGE from a variable, unless --force is used.`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			inFile := args[0]
+			outFile := inFile // Default to the input file if no output file is provided
+			if len(args) == 2 {
+				outFile = args[1]
+			}
+
+			apjFile := mpagd.NewAPJFile(inFile)
+			if err := apjFile.ReadAPJ(); err != nil {
+				return fmt.Errorf("failed to read APJ file: %w", err)
+			}
+
+			duplicates := apjFile.FindDuplicateSprites(spectrumOnly)
+			for _, duplicate := range duplicates {
+				mpagd.LogMessage("Cmd_DedupeSprites", fmt.Sprintf("Sprite %d is the same as sprite %d", duplicate.ID, duplicate.Of), "info", noColor)
+			}
+			if dryRun || len(duplicates) == 0 {
+				mpagd.LogMessage("Cmd_DedupeSprites", fmt.Sprintf("%d duplicate sprites found", len(duplicates)), "ok", noColor)
+				return nil
+			}
+
+			// Numbers in the code can not be changed safely when it uses variables for them
+			dynamic, err := apjFile.FindDynamicImages()
+			if err != nil {
+				return fmt.Errorf("failed to read the event code: %w", err)
+			}
+			logCodeReferences("Cmd_DedupeSprites", "sets the image from", dynamic)
+			if len(dynamic) > 0 && !force {
+				mpagd.LogMessage("Cmd_DedupeSprites", "Sprites not merged as the code sets images from variables, use --force to merge them", "warning", noColor)
+				return nil
+			}
+
+			// If the output file is the same as the input file, create a backup including the code files
+			if outFile == inFile {
+				if err := apjFile.BackupProjectFile(true); err != nil {
+					return fmt.Errorf("failed to create backup: %w", err)
+				}
+				mpagd.LogMessage("Cmd_DedupeSprites", fmt.Sprintf("Backup created: %s", inFile), "ok", noColor)
+			}
+
+			if err := apjFile.MergeSprites(duplicates, outFile); err != nil {
+				return fmt.Errorf("failed to merge sprites: %w", err)
+			}
+
+			if err := apjFile.WriteAPJ(outFile); err != nil {
+				return fmt.Errorf("failed to write APJ file: %w", err)
+			}
+
+			mpagd.LogMessage("Cmd_DedupeSprites", fmt.Sprintf("%d duplicate sprites merged successfully, %d sprites left", len(duplicates), apjFile.NrOfSprites), "ok", noColor)
+			return nil
+		},
+	}
+	cmd.Flags().BoolVarP(&dryRun, "dry-run", "d", false, "List the sprites that would be merged without changing the project")
+	cmd.Flags().BoolVarP(&spectrumOnly, "spectrum-only", "s", false, "Only compare the Spectrum data, the data of the other platforms of the duplicates is lost")
+	cmd.Flags().BoolVarP(&force, "force", "f", false, "Merge sprites even when the code uses variables for them")
+	return cmd
+}
+
+// editSpriteFrames reads the project, applies the frame edit and writes the project,
+// creating a backup when the project is updated in place.
+func editSpriteFrames(element string, args []string, outIndex int, edit func(apj *mpagd.APJFile) (string, error)) error {
+	inFile := args[0]
+	outFile := inFile // Default to the input file if no output file is provided
+	if len(args) > outIndex {
+		outFile = args[outIndex]
+	}
+
+	apjFile, err := readProjectForUpdate(element, inFile, outFile, false)
+	if err != nil {
+		return err
+	}
+
+	message, err := edit(apjFile)
+	if err != nil {
+		return fmt.Errorf("failed to edit sprite frames: %w", err)
+	}
+
+	if err := apjFile.WriteAPJ(outFile); err != nil {
+		return fmt.Errorf("failed to write APJ file: %w", err)
+	}
+
+	mpagd.LogMessage(element, message, "ok", noColor)
+	return nil
+}
+
+// Cmd_AddSpriteFrame creates a command to add a blank frame to a sprite.
+func Cmd_AddSpriteFrame() *cobra.Command {
+	var position int
+	var cmd = &cobra.Command{
+		Use:   "add [apj file] [sprite] [[output file]]",
+		Short: "Add a blank frame to a sprite.",
+		Long:  `Add a blank frame to the end of a sprite, or at the frame position given with --position.`,
+		Args:  cobra.RangeArgs(2, 3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sprite, err := strconv.Atoi(args[1])
+			if err != nil {
+				return fmt.Errorf("invalid sprite index: %w", err)
+			}
+			return editSpriteFrames("Cmd_AddSpriteFrame", args, 2, func(apj *mpagd.APJFile) (string, error) {
+				if err := apj.AddSpriteFrame(sprite, position); err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("Frame added to sprite %d, it now has %d frames", sprite, apj.Sprites[sprite].Frames), nil
+			})
+		},
+	}
+	cmd.Flags().IntVarP(&position, "position", "p", -1, "Position of the new frame, the end of the sprite by default")
+	return cmd
+}
+
+// Cmd_CopySpriteFrame creates a command to copy a frame of a sprite.
+func Cmd_CopySpriteFrame() *cobra.Command {
+	var position int
+	var cmd = &cobra.Command{
+		Use:   "copy [apj file] [sprite] [frame] [[output file]]",
+		Short: "Copy a frame of a sprite.",
+		Long:  `Copy a frame of a sprite to the position after it, or to the frame position given with --position.`,
+		Args:  cobra.RangeArgs(3, 4),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sprite, err := strconv.Atoi(args[1])
+			if err != nil {
+				return fmt.Errorf("invalid sprite index: %w", err)
+			}
+			frame, err := strconv.Atoi(args[2])
+			if err != nil {
+				return fmt.Errorf("invalid frame: %w", err)
+			}
+			return editSpriteFrames("Cmd_CopySpriteFrame", args, 3, func(apj *mpagd.APJFile) (string, error) {
+				if err := apj.CopySpriteFrame(sprite, frame, position); err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("Frame %d of sprite %d copied, it now has %d frames", frame, sprite, apj.Sprites[sprite].Frames), nil
+			})
+		},
+	}
+	cmd.Flags().IntVarP(&position, "position", "p", -1, "Position of the copy, after the frame by default")
+	return cmd
+}
+
+// Cmd_RemoveSpriteFrame creates a command to remove a frame from a sprite.
+func Cmd_RemoveSpriteFrame() *cobra.Command {
+	return &cobra.Command{
+		Use:   "remove [apj file] [sprite] [frame] [[output file]]",
+		Short: "Remove a frame from a sprite.",
+		Long:  `Remove a frame from a sprite. The last frame of a sprite can not be removed.`,
+		Args:  cobra.RangeArgs(3, 4),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sprite, err := strconv.Atoi(args[1])
+			if err != nil {
+				return fmt.Errorf("invalid sprite index: %w", err)
+			}
+			frame, err := strconv.Atoi(args[2])
+			if err != nil {
+				return fmt.Errorf("invalid frame: %w", err)
+			}
+			return editSpriteFrames("Cmd_RemoveSpriteFrame", args, 3, func(apj *mpagd.APJFile) (string, error) {
+				if err := apj.RemoveSpriteFrame(sprite, frame); err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("Frame %d removed from sprite %d, it now has %d frames", frame, sprite, apj.Sprites[sprite].Frames), nil
+			})
+		},
+	}
+}
+
+// Cmd_ReorderSpriteFrames creates a command to reorder the frames of a sprite.
+func Cmd_ReorderSpriteFrames() *cobra.Command {
+	return &cobra.Command{
+		Use:   "reorder [apj file] [sprite] [order] [[output file]]",
+		Short: "Reorder the frames of a sprite.",
+		Long:  `Reorder the frames of a sprite, the order lists every frame once, e.g. "3,0,1,2".`,
+		Args:  cobra.RangeArgs(3, 4),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sprite, err := strconv.Atoi(args[1])
+			if err != nil {
+				return fmt.Errorf("invalid sprite index: %w", err)
+			}
+			order, err := mpagd.ParseIntList(args[2])
+			if err != nil {
+				return fmt.Errorf("invalid order: %w", err)
+			}
+			return editSpriteFrames("Cmd_ReorderSpriteFrames", args, 3, func(apj *mpagd.APJFile) (string, error) {
+				if err := apj.ReorderSpriteFrames(sprite, order); err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("Frames of sprite %d reordered", sprite), nil
+			})
+		},
+	}
+}
+
+// Cmd_JoinSprites creates a command to join sprites into one multi-frame sprite.
+func Cmd_JoinSprites() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "merge [apj file] [sprite ids] [[output file]]",
+		Short: "Join sprites into one multi-frame sprite.",
+		Long: `Join sprites into one multi-frame sprite, e.g. "4,5,6,7". The frames of the other sprites are added to the
+first sprite in the list and the other sprites are removed. Sprite positions and the event code using a removed
+sprite are changed to the joined sprite.`,
+		Args: cobra.RangeArgs(2, 3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			inFile := args[0]
+			outFile := inFile // Default to the input file if no output file is provided
+			if len(args) == 3 {
+				outFile = args[2]
+			}
+
+			ids, err := mpagd.ParseIntList(args[1])
+			if err != nil {
+				return fmt.Errorf("invalid sprite ids: %w", err)
+			}
+
+			apjFile := mpagd.NewAPJFile(inFile)
+			if err := apjFile.ReadAPJ(); err != nil {
+				return fmt.Errorf("failed to read APJ file: %w", err)
+			}
+
+			// If the output file is the same as the input file, create a backup including the code files
+			if outFile == inFile {
+				if err := apjFile.BackupProjectFile(true); err != nil {
+					return fmt.Errorf("failed to create backup: %w", err)
+				}
+				mpagd.LogMessage("Cmd_JoinSprites", fmt.Sprintf("Backup created: %s", inFile), "ok", noColor)
+			}
+
+			joined, err := apjFile.JoinSprites(ids, outFile)
+			if err != nil {
+				return fmt.Errorf("failed to merge sprites: %w", err)
+			}
+
+			if err := apjFile.WriteAPJ(outFile); err != nil {
+				return fmt.Errorf("failed to write APJ file: %w", err)
+			}
+
+			mpagd.LogMessage("Cmd_JoinSprites", fmt.Sprintf("%d sprites merged into sprite %d, it now has %d frames", len(ids), joined, apjFile.Sprites[joined].Frames), "ok", noColor)
+			return nil
+		},
+	}
+	return cmd
+}
+
+// Cmd_SplitSprite creates a command to split the frames of a sprite into separate sprites.
+func Cmd_SplitSprite() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "split [apj file] [sprite] [[output file]]",
+		Short: "Split the frames of a sprite into separate sprites.",
+		Long: `Split the frames of a sprite into separate sprites. The sprite keeps its first frame and each other frame
+becomes a new sprite after it. Sprite positions and the event code using the sprites after it are renumbered.`,
+		Args: cobra.RangeArgs(2, 3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			inFile := args[0]
+			outFile := inFile // Default to the input file if no output file is provided
+			if len(args) == 3 {
+				outFile = args[2]
+			}
+
+			sprite, err := strconv.Atoi(args[1])
+			if err != nil {
+				return fmt.Errorf("invalid sprite index: %w", err)
+			}
+
+			apjFile := mpagd.NewAPJFile(inFile)
+			if err := apjFile.ReadAPJ(); err != nil {
+				return fmt.Errorf("failed to read APJ file: %w", err)
+			}
+
+			// If the output file is the same as the input file, create a backup including the code files
+			if outFile == inFile {
+				if err := apjFile.BackupProjectFile(true); err != nil {
+					return fmt.Errorf("failed to create backup: %w", err)
+				}
+				mpagd.LogMessage("Cmd_SplitSprite", fmt.Sprintf("Backup created: %s", inFile), "ok", noColor)
+			}
+
+			added, err := apjFile.SplitSprite(sprite, outFile)
+			if err != nil {
+				return fmt.Errorf("failed to split sprite: %w", err)
+			}
+
+			if err := apjFile.WriteAPJ(outFile); err != nil {
+				return fmt.Errorf("failed to write APJ file: %w", err)
+			}
+
+			mpagd.LogMessage("Cmd_SplitSprite", fmt.Sprintf("Sprite %d split into sprites %d to %d", sprite, sprite, sprite+added), "ok", noColor)
+			return nil
+		},
+	}
+	return cmd
+}
+
+// Cmd_SpriteFromBlocks creates a command to build a sprite frame from four blocks.
+func Cmd_SpriteFromBlocks() *cobra.Command {
+	var spriteIndex int
+	var cmd = &cobra.Command{
+		Use:   "from-blocks [project file] [top left] [top right] [bottom left] [bottom right] [[output file]]",
+		Short: "Build a sprite frame from four blocks.",
+		Long: `Build a 16x16 sprite frame from the bitmaps of four 8x8 blocks. The frame is added as a new sprite,
+or as the last frame of an existing sprite with --sprite. Only the Spectrum bitmap is used, the block colours are ignored.`,
+		Args: cobra.RangeArgs(5, 6),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			inFile := args[0]
+			outFile := inFile // Default to the input file if no output file is provided
+			if len(args) == 6 {
+				outFile = args[5]
+			}
+
+			var blocks [4]int
+			for i := range blocks {
+				blockID, err := strconv.Atoi(args[i+1])
+				if err != nil {
+					return fmt.Errorf("invalid block number: %w", err)
+				}
+				blocks[i] = blockID
+			}
+
+			apjFile := mpagd.NewAPJFile(inFile)
+			if err := apjFile.ReadAPJ(); err != nil {
+				return fmt.Errorf("failed to read APJ file: %w", err)
+			}
+
+			// If the output file is the same as the input file, create a backup
+			if outFile == inFile {
+				if err := apjFile.BackupProjectFile(false); err != nil {
+					return fmt.Errorf("failed to create backup: %w", err)
+				}
+				mpagd.LogMessage("Cmd_SpriteFromBlocks", fmt.Sprintf("Backup created: %s", inFile), "ok", noColor)
+			}
+
+			sprite, err := apjFile.AddSpriteFromBlocks(blocks, spriteIndex)
+			if err != nil {
+				return fmt.Errorf("failed to build sprite from blocks: %w", err)
+			}
+
+			if err := apjFile.WriteAPJ(outFile); err != nil {
+				return fmt.Errorf("failed to write APJ file: %w", err)
+			}
+
+			mpagd.LogMessage("Cmd_SpriteFromBlocks", fmt.Sprintf("Frame %d of sprite %d built from blocks %d, %d, %d and %d", apjFile.Sprites[sprite].Frames-1, sprite, blocks[0], blocks[1], blocks[2], blocks[3]), "ok", noColor)
+			return nil
+		},
+	}
+	cmd.Flags().IntVarP(&spriteIndex, "sprite", "s", -1, "Add the frame to this sprite instead of a new sprite")
+	return cmd
+}
+
 func init() {
 	RootCmd.AddCommand(spriteCmd)
 	spriteCmd.AddCommand(Cmd_ImportSprites())
+	spriteCmd.AddCommand(Cmd_ImportSpritesPNG())
 	spriteCmd.AddCommand(Cmd_RenderSprite())
 	spriteCmd.AddCommand(spriteRotateCmd)
 	spriteRotateCmd.AddCommand(Cmd_RotateSpritesCCW90())
 	spriteRotateCmd.AddCommand(Cmd_RotateSpritesCW90())
+	spriteCmd.AddCommand(spriteFlipCmd)
+	spriteFlipCmd.AddCommand(Cmd_FlipSprite(false))
+	spriteFlipCmd.AddCommand(Cmd_FlipSprite(true))
 	spriteCmd.AddCommand(Cmd_RenderSpriteToBitmap())
+	spriteCmd.AddCommand(Cmd_RenderSpriteToGIF())
 	spriteCmd.AddCommand(Cmd_ReorderSprites())
+	spriteCmd.AddCommand(Cmd_DeleteSprites())
+	spriteCmd.AddCommand(Cmd_InsertSprite())
+	spriteCmd.AddCommand(Cmd_DedupeSprites())
+	spriteCmd.AddCommand(Cmd_JoinSprites())
+	spriteCmd.AddCommand(Cmd_SplitSprite())
+	spriteCmd.AddCommand(Cmd_SpriteFromBlocks())
+	spriteCmd.AddCommand(spriteFramesCmd)
+	spriteFramesCmd.AddCommand(Cmd_AddSpriteFrame())
+	spriteFramesCmd.AddCommand(Cmd_CopySpriteFrame())
+	spriteFramesCmd.AddCommand(Cmd_RemoveSpriteFrame())
+	spriteFramesCmd.AddCommand(Cmd_ReorderSpriteFrames())
 }
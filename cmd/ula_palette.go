@@ -53,8 +53,8 @@ func Cmd_ImportULAPalette() *cobra.Command {
 
 			// Configure import options
 			options := mpagd.CreateImportOptions()
-			options.SetIgnoreOptions(true, true, true, true, true, true, true, true, true)
-			options.SetOwOptions(false, false, false, false, false, false, false, false, replace)
+			options.SetIgnoreOptions(true, true, true, true, true, true, true, true, true, true)
+			options.SetOwOptions(false, false, false, false, false, false, false, false, replace, false)
 
 			// Perform the import
 			if err := apj.ImportAGD(agdFilePath, options); err != nil {
@@ -16,7 +16,7 @@ func ReadBlankProjectImportWriteCompare() {
 	agdFilePath := "deleteme.agd"
 	opt := mpagd.CreateImportOptions()
 	opt.SetOwOptionsTrue()
-	opt.SetIgnoreOptions(true, true, false, true, true, true, true, true, true)
+	opt.SetIgnoreOptions(true, true, false, true, true, true, true, true, true, true)
 	apjFile.ImportAGD(agdFilePath, opt)
 
 	// // Example comparison
@@ -1,5 +1,11 @@
 package mpagd
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
 // IdToBlockType maps block IDs to their corresponding block type names.
 var IdToBlockType = map[uint8]string{
 	0: "EMPTYBLOCK",
@@ -45,3 +51,73 @@ func GetBlockTypeIDByType(blockType string) uint8 {
 	}
 	return 0 // Default value for unknown block types.
 }
+
+// ParseBlockType returns the block type given by name, such as WALLBLOCK, or by number.
+func ParseBlockType(typeName string) (uint8, error) {
+	typeName = strings.ToUpper(strings.TrimSpace(typeName))
+	if blockType, exists := BlockTypeToId[typeName]; exists {
+		return blockType, nil
+	}
+	value, err := strconv.Atoi(typeName)
+	if err != nil || value < 0 || value > 255 {
+		return 0, fmt.Errorf("invalid block type: %s", typeName)
+	}
+	return uint8(value), nil
+}
+
+// BlockTypeRange assigns a block type to a range of cells when importing blocks from an image.
+type BlockTypeRange struct {
+	Start int   // First cell of the range
+	End   int   // Last cell of the range, -1 for all remaining cells
+	Type  uint8 // Block type for the cells in the range
+}
+
+// ParseBlockTypeRanges parses a list of cell ranges and block types, e.g. "0-3:WALLBLOCK,4:LADDERBLOCK,5-:1".
+// Block types can be given by name or number.
+func ParseBlockTypeRanges(spec string) ([]BlockTypeRange, error) {
+	var ranges []BlockTypeRange
+	if strings.TrimSpace(spec) == "" {
+		return ranges, nil
+	}
+	for _, part := range strings.Split(spec, ",") {
+		cells, typeName, found := strings.Cut(strings.TrimSpace(part), ":")
+		if !found {
+			return nil, fmt.Errorf("invalid block type range: %s", part)
+		}
+
+		blockType, err := ParseBlockType(typeName)
+		if err != nil {
+			return nil, err
+		}
+
+		// Parse the cell range, an open end applies to all remaining cells
+		startStr, endStr, isRange := strings.Cut(strings.TrimSpace(cells), "-")
+		start, err := strconv.Atoi(startStr)
+		if err != nil || start < 0 {
+			return nil, fmt.Errorf("invalid cell range: %s", cells)
+		}
+		end := start
+		if isRange {
+			end = -1
+			if endStr != "" {
+				end, err = strconv.Atoi(endStr)
+				if err != nil || end < start {
+					return nil, fmt.Errorf("invalid cell range: %s", cells)
+				}
+			}
+		}
+		ranges = append(ranges, BlockTypeRange{Start: start, End: end, Type: blockType})
+	}
+	return ranges, nil
+}
+
+// blockTypeForCell returns the block type for a cell, the last matching range wins.
+func blockTypeForCell(ranges []BlockTypeRange, cell int) uint8 {
+	blockType := BlockTypeToId["EMPTYBLOCK"]
+	for _, r := range ranges {
+		if cell >= r.Start && (r.End == -1 || cell <= r.End) {
+			blockType = r.Type
+		}
+	}
+	return blockType
+}
@@ -4,8 +4,8 @@ import (
 	"encoding/binary"
 	"fmt"
 	"image"
-	"image/png"
 	"io"
+	"math/bits"
 	"os"
 	"regexp"
 	"strings"
@@ -165,6 +165,65 @@ func (apj *APJFile) ImportBlocks(lines []string) error {
 	return nil
 }
 
+// ImportBlocksFromPNG cuts a PNG tileset into 8x8 cells and converts each cell into a block.
+// The blocks are appended, or replace the existing blocks when replace is true.
+// gap is the number of pixels between the cells. It returns the cells that use more colours
+// than a Spectrum attribute allows.
+func (apj *APJFile) ImportBlocksFromPNG(filePath string, types []BlockTypeRange, replace bool, gap int) ([]PNGCellIssue, error) {
+	img, err := ReadPNG(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PNG file: %w", err)
+	}
+
+	size := 8
+	columns, rows := CountPNGCells(img, size, gap)
+	if columns == 0 || rows == 0 {
+		return nil, fmt.Errorf("image is smaller than a block: %s", filePath)
+	}
+
+	apj.BlockInit(replace)
+	if len(apj.Blocks)+columns*rows > 255 {
+		return nil, fmt.Errorf("too many blocks: %d existing and %d in the image", len(apj.Blocks), columns*rows)
+	}
+
+	var issues []PNGCellIssue
+	bounds := img.Bounds()
+	for row := 0; row < rows; row++ {
+		for col := 0; col < columns; col++ {
+			cell := row*columns + col
+			data, issue := cellToSpectrum(img, bounds.Min.X+col*(size+gap), bounds.Min.Y+row*(size+gap))
+			if issue != nil {
+				issue.Cell, issue.Column, issue.Row = cell, col, row
+				issues = append(issues, *issue)
+			}
+
+			block := apj.createBlock(uint8(len(apj.Blocks)), blockTypeForCell(types, cell))
+			block.Spectrum = data
+			apj.Blocks = append(apj.Blocks, block)
+		}
+	}
+
+	apj.NrOfBlocks = uint8(len(apj.Blocks))
+	apj.State.Blocks = true
+	return issues, nil
+}
+
+// exportBlocks writes a DEFINEBLOCK directive for each block to the given writer.
+func (apj *APJFile) exportBlocks(f io.Writer) error {
+	for _, block := range apj.Blocks {
+		if err := writeAGDLine(f, "DEFINEBLOCK", GetBlockTypeByTypeID(block.Type)); err != nil {
+			return err
+		}
+		if err := writeAGDLine(f, "", formatValues(block.Spectrum, "%d")); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintln(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // RotateBlock rotates a block's Spectrum data 90 degrees counter-clockwise or clockwise.
 func (apj *APJFile) RotateBlock(blockIndex uint8, ccw bool, retain bool) (uint8, error) {
 	if blockIndex >= uint8(len(apj.Blocks)) {
@@ -227,6 +286,40 @@ func (apj *APJFile) blockRotate(data []uint8, ccw bool) []uint8 {
 	return rotated
 }
 
+// FlipBlock mirrors a block's Spectrum data horizontally, or vertically when vertical is true.
+func (apj *APJFile) FlipBlock(blockIndex uint8, vertical bool, retain bool) (uint8, error) {
+	if blockIndex >= uint8(len(apj.Blocks)) {
+		return blockIndex, fmt.Errorf("block index out of range: %d", blockIndex)
+	}
+	var block Block
+	if retain {
+		block = apj.createBlock(uint8(len(apj.Blocks)), apj.Blocks[blockIndex].Type)
+	} else {
+		block = apj.Blocks[blockIndex]
+	}
+	block.Spectrum = blockFlip(apj.Blocks[blockIndex].Spectrum, vertical)
+	if retain {
+		apj.Blocks = append(apj.Blocks, block)
+		apj.NrOfBlocks++
+	} else {
+		apj.Blocks[blockIndex] = block
+	}
+	return uint8(len(apj.Blocks) - 1), nil
+}
+
+// blockFlip mirrors the 8 bitmap rows of the block data, the attribute byte is kept.
+func blockFlip(data []uint8, vertical bool) []uint8 {
+	flipped := append([]uint8(nil), data...)
+	for i := 0; i < 8; i++ {
+		if vertical {
+			flipped[i] = data[7-i]
+		} else {
+			flipped[i] = bits.Reverse8(data[i])
+		}
+	}
+	return flipped
+}
+
 // RenderBlockToTerminal renders blocks to the terminal.
 /*func (apj *APJFile) RenderBlockToTerminal(startBlock, endBlock uint8, reorder []int) error {
 	if startBlock >= apj.NrOfBlocks || endBlock > apj.NrOfBlocks {
@@ -328,9 +421,18 @@ func (apj *APJFile) RenderBlockToSeperateBitmap(startIndex, endIndex uint8, Imag
 }
 
 func (apj *APJFile) RenderBlockToBitmap(startIndex, endIndex uint8, filePath string, reorder []int, offset int) error {
+	img, err := apj.RenderBlockImage(startIndex, endIndex, reorder, offset)
+	if err != nil {
+		return err
+	}
+	return writePNG(filePath, img)
+}
+
+// RenderBlockImage draws a range of blocks into an image, 8 blocks per row.
+func (apj *APJFile) RenderBlockImage(startIndex, endIndex uint8, reorder []int, offset int) (*image.RGBA, error) {
 	// Validate sprite indexes
 	if startIndex >= uint8(len(apj.Blocks)) || endIndex > uint8(len(apj.Blocks)) || startIndex >= endIndex {
-		return fmt.Errorf("blocks index range out of bounds: %d-%d", startIndex, endIndex)
+		return nil, fmt.Errorf("blocks index range out of bounds: %d-%d", startIndex, endIndex)
 	}
 
 	// Calculate the number of sprites and layout
@@ -349,7 +451,7 @@ func (apj *APJFile) RenderBlockToBitmap(startIndex, endIndex uint8, filePath str
 		// Reorder the blocks based on the provided order
 		data, err = apj.GetReorderedBlocks(reorder, offset)
 		if err != nil {
-			return fmt.Errorf("failed to reorder blocks: %s", err)
+			return nil, fmt.Errorf("failed to reorder blocks: %s", err)
 		}
 	} else {
 		data = apj.Blocks
@@ -358,7 +460,7 @@ func (apj *APJFile) RenderBlockToBitmap(startIndex, endIndex uint8, filePath str
 	for Index := startIndex; Index < endIndex; Index++ {
 		sp, err := apj.BlockTo2DArray(data[Index].Spectrum)
 		if err != nil {
-			return fmt.Errorf("failed to convert sprite to array: %s", err)
+			return nil, fmt.Errorf("failed to convert sprite to array: %s", err)
 		}
 
 		// Calculate sprite position in the image
@@ -383,17 +485,7 @@ func (apj *APJFile) RenderBlockToBitmap(startIndex, endIndex uint8, filePath str
 		}
 	}
 
-	file, err := os.Create(filePath)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	if err := png.Encode(file, img); err != nil {
-		return err
-	}
-
-	return nil
+	return img, nil
 }
 
 // ReorderBlocks reorders the blocks based on the provided order.
@@ -481,3 +573,146 @@ func (apj *APJFile) GetReorderedBlocks(order []int, offset int) ([]Block, error)
 
 	return newBlocks, nil
 }
+
+// DeleteBlocksMapping returns the new ID of every block after the ids are deleted and replaced
+// with the substitute block.
+func (apj *APJFile) DeleteBlocksMapping(ids []int, substitute int) []int {
+	mapping := deleteMapping(len(apj.Blocks), ids)
+	if substitute >= 0 && substitute < len(mapping) {
+		for _, id := range ids {
+			if id >= 0 && id < len(mapping) {
+				mapping[id] = mapping[substitute]
+			}
+		}
+	}
+	return mapping
+}
+
+// DeleteBlocks removes the given blocks and updates the screens and the PUTBLOCK statements in the
+// event code to match. Screen cells and code using a deleted block are changed to the substitute
+// block, and those using a higher block are shifted down. The code is written to the event files
+// of the project at codePath.
+func (apj *APJFile) DeleteBlocks(ids []int, substitute int, codePath string) error {
+	mapping, err := apj.deleteBlocks(ids, substitute)
+	if err != nil {
+		return err
+	}
+	codeMappings := make(map[*regexp.Regexp][]int)
+	for _, pattern := range codeBlockPatterns {
+		codeMappings[pattern] = mapping
+	}
+	return apj.remapEventCode(codePath, codeMappings)
+}
+
+// deleteBlocks removes the given blocks without changing the event code, it returns the new ID of every old block.
+func (apj *APJFile) deleteBlocks(ids []int, substitute int) ([]int, error) {
+	deleted := make(map[int]bool)
+	for _, id := range ids {
+		if id < 0 || id >= len(apj.Blocks) {
+			return nil, fmt.Errorf("block %d does not exist", id)
+		}
+		deleted[id] = true
+	}
+	if len(deleted) >= len(apj.Blocks) {
+		return nil, fmt.Errorf("can not delete all the blocks")
+	}
+	if substitute < 0 || substitute >= len(apj.Blocks) {
+		return nil, fmt.Errorf("substitute block %d does not exist", substitute)
+	}
+	if deleted[substitute] {
+		return nil, fmt.Errorf("substitute block %d is being deleted", substitute)
+	}
+
+	replacements := make(map[int]int)
+	for id := range deleted {
+		replacements[id] = substitute
+	}
+	return apj.replaceBlocks(replacements), nil
+}
+
+// replaceBlocks removes the blocks in replacements and changes the screen cells using them to the
+// block they are replaced by. It returns the new ID of every old block.
+func (apj *APJFile) replaceBlocks(replacements map[int]int) []int {
+	mapping := make([]int, len(apj.Blocks))
+	newBlocks := make([]Block, 0, len(apj.Blocks)-len(replacements))
+	for i, block := range apj.Blocks {
+		if _, replaced := replacements[i]; replaced {
+			continue
+		}
+		mapping[i] = len(newBlocks)
+		block.ID = uint8(len(newBlocks))
+		newBlocks = append(newBlocks, block)
+	}
+	for id, replacement := range replacements {
+		mapping[id] = mapping[replacement]
+	}
+
+	apj.remapScreenBlocks(mapping)
+	apj.Blocks = newBlocks
+	apj.NrOfBlocks = uint8(len(apj.Blocks))
+	return mapping
+}
+
+// remapScreenBlocks changes every screen cell from its old block ID to mapping[old ID].
+// Cells using a block outside the mapping are left as they are.
+func (apj *APJFile) remapScreenBlocks(mapping []int) {
+	for i := range apj.Screens {
+		for _, row := range apj.Screens[i].ScreenData {
+			for j, blockID := range row {
+				if int(blockID) < len(mapping) {
+					row[j] = uint8(mapping[blockID])
+				}
+			}
+		}
+	}
+}
+
+// Duplicate is a block or sprite that is the same as an earlier one.
+type Duplicate struct {
+	ID int // The duplicate
+	Of int // The earlier block or sprite it is the same as
+}
+
+// FindDuplicateBlocks returns the blocks with the same type and data on every platform as an
+// earlier block. With spectrumOnly only the Spectrum bitmap and attribute are compared.
+func (apj *APJFile) FindDuplicateBlocks(spectrumOnly bool) []Duplicate {
+	duplicates := make([]Duplicate, 0)
+	first := make(map[string]int)
+	for i, block := range apj.Blocks {
+		key := fmt.Sprintf("%d:%x", block.Type, block.Spectrum)
+		if !spectrumOnly {
+			key += fmt.Sprintf(":%x:%x:%x:%x:%x", block.Timex, block.CPC, block.Atom, block.MSX, block.AtomColour)
+		}
+		if id, exists := first[key]; exists {
+			duplicates = append(duplicates, Duplicate{ID: i, Of: id})
+			continue
+		}
+		first[key] = i
+	}
+	return duplicates
+}
+
+// MergeBlocks removes the duplicate blocks and changes the screens and the PUTBLOCK statements
+// in the event code to use the block they duplicate. The code is written to the event files
+// of the project at codePath.
+func (apj *APJFile) MergeBlocks(duplicates []Duplicate, codePath string) error {
+	replacements := make(map[int]int)
+	for _, duplicate := range duplicates {
+		if duplicate.ID < 0 || duplicate.ID >= len(apj.Blocks) || duplicate.Of < 0 || duplicate.Of >= len(apj.Blocks) {
+			return fmt.Errorf("block %d or %d does not exist", duplicate.ID, duplicate.Of)
+		}
+		replacements[duplicate.ID] = duplicate.Of
+	}
+	for _, replacement := range replacements {
+		if _, replaced := replacements[replacement]; replaced {
+			return fmt.Errorf("block %d is merged into a block that is also merged", replacement)
+		}
+	}
+
+	mapping := apj.replaceBlocks(replacements)
+	codeMappings := make(map[*regexp.Regexp][]int)
+	for _, pattern := range codeBlockPatterns {
+		codeMappings[pattern] = mapping
+	}
+	return apj.remapEventCode(codePath, codeMappings)
+}
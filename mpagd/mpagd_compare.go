@@ -6,7 +6,7 @@ import (
 )
 
 // CompareData compares the data in the current APJFile with another APJFile
-// and returns a map of differences.
+// and returns a map of differences. Use Diff for a comparison of every section.
 func (apj *APJFile) CompareData(other *APJFile) map[string]interface{} {
 	differences := make(map[string]interface{})
 
@@ -37,8 +37,21 @@ func (apj *APJFile) CompareData(other *APJFile) map[string]interface{} {
 		}
 	}
 
-	// Compare other fields if necessary
-	// ...existing code for other fields...
+	// Compare Map
+	if !deepEqual(apj.Map, other.Map) {
+		differences["Map"] = map[string]interface{}{
+			"self":  apj.Map,
+			"other": other.Map,
+		}
+	}
+
+	// Compare Messages
+	if !deepEqual(apj.Messages, other.Messages) {
+		differences["Messages"] = map[string]interface{}{
+			"self":  apj.Messages,
+			"other": other.Messages,
+		}
+	}
 
 	return differences
 }
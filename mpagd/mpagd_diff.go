@@ -0,0 +1,194 @@
+package mpagd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// DiffEntry describes a single difference between two projects.
+type DiffEntry struct {
+	Section string      `json:"section"`         // Top level APJFile field, e.g. Blocks
+	Path    string      `json:"path"`            // Path to the value, e.g. Screens[2].ScreenData[3][4]
+	Kind    string      `json:"kind"`            // "changed", "added" or "removed"
+	Self    interface{} `json:"self,omitempty"`  // Value in the first project
+	Other   interface{} `json:"other,omitempty"` // Value in the second project
+}
+
+// ProjectDiff holds the differences between two projects.
+type ProjectDiff struct {
+	Self    string      `json:"self"`    // Path of the first project
+	Other   string      `json:"other"`   // Path of the second project
+	Entries []DiffEntry `json:"entries"` // Differences in the order of the APJFile fields
+}
+
+// diffSkipFields are APJFile fields that describe the loaded file rather than the project.
+var diffSkipFields = map[string]bool{
+	"FilePath":    true,
+	"Description": true,
+	"State":       true,
+}
+
+// diffByElement are byte slice fields compared value by value, such as screen and map cells.
+// Other byte slices, like block bitmaps and sprite frames, are compared as a whole.
+var diffByElement = map[string]bool{
+	"ScreenData": true,
+	"Map":        true,
+	"Keys":       true,
+	"Colors":     true,
+}
+
+// Diff compares every section of the project with another project,
+// down to individual blocks, sprite frames, screen cells and map cells.
+func (apj *APJFile) Diff(other *APJFile) ProjectDiff {
+	diff := ProjectDiff{Self: apj.FilePath, Other: other.FilePath, Entries: make([]DiffEntry, 0)}
+	selfValue := reflect.ValueOf(apj).Elem()
+	otherValue := reflect.ValueOf(other).Elem()
+	for i := 0; i < selfValue.NumField(); i++ {
+		field := selfValue.Type().Field(i)
+		if !field.IsExported() || diffSkipFields[field.Name] {
+			continue
+		}
+		diff.diffValue(field.Name, field.Name, field.Name, selfValue.Field(i), otherValue.Field(i))
+	}
+	return diff
+}
+
+// diffValue compares two values and adds an entry for each difference found.
+func (diff *ProjectDiff) diffValue(section, path, name string, self, other reflect.Value) {
+	switch self.Kind() {
+	case reflect.Struct:
+		for i := 0; i < self.NumField(); i++ {
+			field := self.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			diff.diffValue(section, path+"."+field.Name, field.Name, self.Field(i), other.Field(i))
+		}
+
+	case reflect.Slice:
+		// Byte slices are a single value unless their cells are compared one by one
+		if self.Type().Elem().Kind() == reflect.Uint8 && !diffByElement[name] {
+			if !bytes.Equal(self.Bytes(), other.Bytes()) {
+				diff.add(section, path, "changed", self, other)
+			}
+			return
+		}
+		for i := 0; i < max(self.Len(), other.Len()); i++ {
+			itemPath := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case i >= other.Len():
+				diff.add(section, itemPath, "removed", self.Index(i), reflect.Value{})
+			case i >= self.Len():
+				diff.add(section, itemPath, "added", reflect.Value{}, other.Index(i))
+			default:
+				diff.diffValue(section, itemPath, name, self.Index(i), other.Index(i))
+			}
+		}
+
+	default:
+		if !reflect.DeepEqual(self.Interface(), other.Interface()) {
+			diff.add(section, path, "changed", self, other)
+		}
+	}
+}
+
+// add appends a difference to the list.
+func (diff *ProjectDiff) add(section, path, kind string, self, other reflect.Value) {
+	diff.Entries = append(diff.Entries, DiffEntry{Section: section, Path: path, Kind: kind, Self: plainValue(self), Other: plainValue(other)})
+}
+
+// plainValue converts a value to maps, slices and numbers, so byte slices are not
+// written as base64 strings in the JSON output.
+func plainValue(value reflect.Value) interface{} {
+	if !value.IsValid() {
+		return nil
+	}
+	switch value.Kind() {
+	case reflect.Struct:
+		fields := make(map[string]interface{})
+		for i := 0; i < value.NumField(); i++ {
+			if value.Type().Field(i).IsExported() {
+				fields[value.Type().Field(i).Name] = plainValue(value.Field(i))
+			}
+		}
+		return fields
+	case reflect.Slice:
+		items := make([]interface{}, value.Len())
+		for i := range items {
+			items[i] = plainValue(value.Index(i))
+		}
+		return items
+	default:
+		return value.Interface()
+	}
+}
+
+// Sections returns the sections with differences in the order they were found.
+func (diff *ProjectDiff) Sections() []string {
+	var sections []string
+	seen := make(map[string]bool)
+	for _, entry := range diff.Entries {
+		if !seen[entry.Section] {
+			seen[entry.Section] = true
+			sections = append(sections, entry.Section)
+		}
+	}
+	return sections
+}
+
+// WriteText writes the differences grouped by section in a readable form.
+func (diff *ProjectDiff) WriteText(w io.Writer) error {
+	if len(diff.Entries) == 0 {
+		_, err := fmt.Fprintf(w, "No differences between %s and %s\n", diff.Self, diff.Other)
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "--- %s\n+++ %s\n", diff.Self, diff.Other); err != nil {
+		return err
+	}
+	for _, section := range diff.Sections() {
+		var lines []string
+		for _, entry := range diff.Entries {
+			if entry.Section != section {
+				continue
+			}
+			switch entry.Kind {
+			case "added":
+				lines = append(lines, fmt.Sprintf("  + %s: %s", entry.Path, formatDiffValue(entry.Other)))
+			case "removed":
+				lines = append(lines, fmt.Sprintf("  - %s: %s", entry.Path, formatDiffValue(entry.Self)))
+			default:
+				lines = append(lines, fmt.Sprintf("  ~ %s: %s -> %s", entry.Path, formatDiffValue(entry.Self), formatDiffValue(entry.Other)))
+			}
+		}
+		if _, err := fmt.Fprintf(w, "%s (%d differences)\n%s\n", section, len(lines), strings.Join(lines, "\n")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteJSON writes the differences as indented JSON.
+func (diff *ProjectDiff) WriteJSON(w io.Writer) error {
+	data, err := json.MarshalIndent(diff, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal diff: %w", err)
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+// formatDiffValue formats a value for the text output, slices as space separated values.
+func formatDiffValue(value interface{}) string {
+	if items, ok := value.([]interface{}); ok {
+		parts := make([]string, len(items))
+		for i, item := range items {
+			parts[i] = formatDiffValue(item)
+		}
+		return "[" + strings.Join(parts, " ") + "]"
+	}
+	return fmt.Sprintf("%v", value)
+}
@@ -0,0 +1,205 @@
+package mpagd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// IdToEventName maps MPAGD event file numbers (.aNN) to their event names.
+var IdToEventName = map[uint8]string{
+	0:  "PLAYER",
+	1:  "SPRITETYPE1",
+	2:  "SPRITETYPE2",
+	3:  "SPRITETYPE3",
+	4:  "SPRITETYPE4",
+	5:  "SPRITETYPE5",
+	6:  "SPRITETYPE6",
+	7:  "SPRITETYPE7",
+	8:  "SPRITETYPE8",
+	9:  "INITSPRITE",
+	10: "MAINLOOP1",
+	11: "MAINLOOP2",
+	12: "INTROMENU",
+	13: "GAMEINIT",
+	14: "RESTARTSCREEN",
+	15: "FELLTOOFAR",
+	16: "KILLPLAYER",
+	17: "LOSTGAME",
+	18: "COMPLETEDGAME",
+	19: "NEWHIGHSCORE",
+	20: "COLLECTBLOCK",
+}
+
+// EventNameToId maps MPAGD event names to their event file numbers (.aNN).
+var EventNameToId = map[string]uint8{
+	"PLAYER":        0,
+	"SPRITETYPE1":   1,
+	"SPRITETYPE2":   2,
+	"SPRITETYPE3":   3,
+	"SPRITETYPE4":   4,
+	"SPRITETYPE5":   5,
+	"SPRITETYPE6":   6,
+	"SPRITETYPE7":   7,
+	"SPRITETYPE8":   8,
+	"INITSPRITE":    9,
+	"MAINLOOP1":     10,
+	"MAINLOOP2":     11,
+	"INTROMENU":     12,
+	"GAMEINIT":      13,
+	"RESTARTSCREEN": 14,
+	"FELLTOOFAR":    15,
+	"KILLPLAYER":    16,
+	"LOSTGAME":      17,
+	"COMPLETEDGAME": 18,
+	"NEWHIGHSCORE":  19,
+	"COLLECTBLOCK":  20,
+}
+
+// NrOfEvents is the number of event files an MPAGD project can have.
+const NrOfEvents = 21
+
+// GetEventNameByID returns the event name for a given event number.
+// If the number does not exist, it returns "UNKNOWN".
+func GetEventNameByID(id uint8) string {
+	if name, exists := IdToEventName[id]; exists {
+		return name
+	}
+	return "UNKNOWN"
+}
+
+// GetEventIDByName returns the event number for a given event name and whether it exists.
+func GetEventIDByName(name string) (uint8, bool) {
+	id, exists := EventNameToId[strings.ToUpper(strings.TrimSpace(name))]
+	return id, exists
+}
+
+// EventFilePath returns the path of the .aNN event file for the given event number.
+func (apj *APJFile) EventFilePath(id uint8) string {
+	fileName := strings.TrimSuffix(filepath.Base(apj.FilePath), filepath.Ext(apj.FilePath))
+	return filepath.Join(filepath.Dir(apj.FilePath), fmt.Sprintf("%s.a%02d", fileName, id))
+}
+
+// exportEvents appends the project's .aNN event files to the given writer.
+// An EVENT line is added when the file does not start with one.
+func (apj *APJFile) exportEvents(f io.Writer) error {
+	for id := uint8(0); id < NrOfEvents; id++ {
+		content, err := os.ReadFile(apj.EventFilePath(id))
+		if err != nil {
+			// skip missing event files
+			continue
+		}
+		code := strings.TrimRight(strings.ReplaceAll(string(content), "\r\n", "\n"), " \t\n")
+		if !strings.HasPrefix(strings.TrimSpace(code), "EVENT") {
+			code = fmt.Sprintf("EVENT %s\n%s", GetEventNameByID(id), code)
+		}
+		if _, err := fmt.Fprintf(f, "%s\n", code); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ImportEvents splits the EVENT sections of an AGD file into the project's .aNN event files.
+// Existing event files are only replaced when overwrite is true. A backup of the project and its
+// code files is created before an existing event file is replaced. It returns the files written.
+func (apj *APJFile) ImportEvents(agdFilePath string, overwrite bool) ([]string, error) {
+	file, err := os.Open(agdFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open AGD file: %w", err)
+	}
+	defer file.Close()
+
+	eventPattern := regexp.MustCompile(`^EVENT\s+(\w+)`)
+	sectionPattern := regexp.MustCompile(`^(DEFINE\w+|MAP\s)`)
+
+	// Collect the code of each event, keeping the original indentation
+	events := make(map[uint8][]string)
+	var order []uint8
+	var current []string
+	var currentID uint8
+	inEvent := false
+
+	flush := func() {
+		if inEvent {
+			events[currentID] = current
+		}
+		inEvent = false
+		current = nil
+	}
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		trimmed := strings.TrimSpace(line)
+
+		if match := eventPattern.FindStringSubmatch(trimmed); match != nil {
+			flush()
+			id, exists := GetEventIDByName(match[1])
+			if !exists {
+				LogMessage("ImportEvents", fmt.Sprintf("Unknown event %s skipped", match[1]), "warning", apj.noColor)
+				continue
+			}
+			if _, exists := events[id]; !exists {
+				order = append(order, id)
+			}
+			currentID = id
+			inEvent = true
+			current = []string{fmt.Sprintf("EVENT %s", GetEventNameByID(id))}
+			continue
+		}
+
+		if sectionPattern.MatchString(trimmed) {
+			flush()
+			continue
+		}
+
+		if inEvent {
+			current = append(current, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading AGD file: %w", err)
+	}
+	flush()
+
+	if len(order) == 0 {
+		return nil, nil
+	}
+
+	// Backup the project and code files before replacing any of them
+	if overwrite && apj.hasEventFiles(order) {
+		if err := apj.BackupProjectFile(true); err != nil {
+			return nil, fmt.Errorf("failed to create backup: %w", err)
+		}
+	}
+
+	var written []string
+	for _, id := range order {
+		eventFile := apj.EventFilePath(id)
+		if _, err := os.Stat(eventFile); err == nil && !overwrite {
+			LogMessage("ImportEvents", fmt.Sprintf("Keeping existing event file %s", eventFile), "warning", apj.noColor)
+			continue
+		}
+		code := strings.TrimRight(strings.Join(events[id], "\n"), " \t\n") + "\n"
+		if err := os.WriteFile(eventFile, []byte(code), 0644); err != nil {
+			return written, fmt.Errorf("failed to write event file: %w", err)
+		}
+		written = append(written, eventFile)
+	}
+	return written, nil
+}
+
+// hasEventFiles returns true when the project has an event file for any of the events.
+func (apj *APJFile) hasEventFiles(ids []uint8) bool {
+	for _, id := range ids {
+		if _, err := os.Stat(apj.EventFilePath(id)); err == nil {
+			return true
+		}
+	}
+	return false
+}
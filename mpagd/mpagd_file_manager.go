@@ -147,7 +147,7 @@ func (apj *APJFile) RestoreLastBackup(backupDir string, code bool) (string, erro
 		extractedFilePath := filepath.Join(filepath.Dir(apj.FilePath), header.Name)
 
 		// If code is true, add the code to the file name
-		if filepath.Ext(extractedFilePath) != ".apj" && !code {
+		if filepath.Ext(extractedFilePath) != ".apj" && filepath.Ext(extractedFilePath) != MessagesFileExt && !code {
 			// Skip files that are not APJ files
 			continue
 		}
@@ -173,7 +173,8 @@ func (apj *APJFile) RestoreLastBackup(backupDir string, code bool) (string, erro
 	return lastBackupFile, nil
 }
 
-// Backup creates a backup of the APJ file in the same directory as the original file
+// Backup creates a backup of the APJ file in the same directory as the original file.
+// Backups made in the same second are numbered _01 to _99, so one does not replace another.
 func (apj *APJFile) BackupProjectFile(code bool) error {
 	// Log start message with parameters
 	LogMessage("BackupProjectFile", fmt.Sprintf("Starting backup for file: %s", apj.FilePath), "info", apj.noColor)
@@ -202,6 +203,10 @@ func (apj *APJFile) BackupProjectFile(code bool) error {
 	var projectFiles []string
 	//read the project folder
 	projectFolder := filepath.Dir(apj.FilePath)
+	// the messages file is part of the project data so always include it
+	if _, err := os.Stat(MessagesFilePath(apj.FilePath)); err == nil {
+		projectFiles = append(projectFiles, filepath.Base(MessagesFilePath(apj.FilePath)))
+	}
 	if code {
 		// get the file name without the extension
 		fileName = strings.TrimSuffix(fileName, ".apj")
@@ -225,8 +230,12 @@ func (apj *APJFile) BackupProjectFile(code bool) error {
 	backupFileName := fmt.Sprintf("%s/backup_%s_%s%s", backupDir, fileName, currentTime, ".tar")
 
 	//tar the project file and files that begin with the project name excluding extension xxxx.a00   xxxx.a01 ...
-	// create a tar file
-	tarFile, err := os.Create(backupFileName)
+	// create a tar file, numbering it when a backup was already made in the same second
+	tarFile, err := os.OpenFile(backupFileName, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+	for count := 1; os.IsExist(err) && count < 100; count++ {
+		backupFileName = fmt.Sprintf("%s/backup_%s_%s_%02d%s", backupDir, fileName, currentTime, count, ".tar")
+		tarFile, err = os.OpenFile(backupFileName, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+	}
 	if err != nil {
 		return err
 	}
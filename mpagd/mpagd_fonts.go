@@ -2,6 +2,9 @@ package mpagd
 
 import (
 	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
 	"io"
 	"strings"
 )
@@ -75,6 +78,21 @@ func (apj *APJFile) ImportFont(lines []string) error {
 	return nil
 }
 
+// exportFont writes the DEFINEFONT directive with one line per character to the given writer.
+func (apj *APJFile) exportFont(f io.Writer) error {
+	for i, font := range apj.Fonts {
+		directive := ""
+		if i == 0 {
+			directive = "DEFINEFONT"
+		}
+		if err := writeAGDLine(f, directive, formatValues(font.Data, "%d")); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintln(f)
+	return err
+}
+
 // readFont reads font data from an io.Reader
 func (apj *APJFile) readFont(f io.Reader) error {
 	font := make([]Font, 96)
@@ -102,3 +120,24 @@ func (apj *APJFile) writeFont(f io.Writer) error {
 	}
 	return nil
 }
+
+// drawText writes the text into the image with the project font, one 8x8 character per letter.
+// Characters the font does not have are left blank.
+func (apj *APJFile) drawText(img *image.RGBA, x0, y0 int, text string, fg, bg color.Color) {
+	for i, char := range text {
+		index := int(char) - 32
+		var data []uint8
+		if index >= 0 && index < len(apj.Fonts) {
+			data = apj.Fonts[index].Data
+		}
+		for y := 0; y < 8; y++ {
+			for x := 0; x < 8; x++ {
+				if y < len(data) && (data[y]>>(7-x))&1 == 1 {
+					img.Set(x0+i*8+x, y0+y, fg)
+				} else {
+					img.Set(x0+i*8+x, y0+y, bg)
+				}
+			}
+		}
+	}
+}
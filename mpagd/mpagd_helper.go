@@ -216,6 +216,30 @@ func CSVToIntSlice(csv string) []int {
 	return result
 }
 
+// ParseIntList converts a comma-separated list of numbers and ranges, e.g. "1,4-6", to a slice of integers.
+// Unlike CSVToIntSlice it returns an error for values that are not numbers.
+func ParseIntList(list string) ([]int, error) {
+	result := make([]int, 0)
+	for _, part := range strings.Split(list, ",") {
+		part = strings.TrimSpace(part)
+		bounds := strings.SplitN(part, "-", 2)
+		start, err := strconv.Atoi(strings.TrimSpace(bounds[0]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", part)
+		}
+		end := start
+		if len(bounds) == 2 {
+			if end, err = strconv.Atoi(strings.TrimSpace(bounds[1])); err != nil || end < start {
+				return nil, fmt.Errorf("invalid range %q", part)
+			}
+		}
+		for i := start; i <= end; i++ {
+			result = append(result, i)
+		}
+	}
+	return result, nil
+}
+
 // ensureDirExists ensures that the directory for the given file path exists
 func ensureDirExists(filePath string) error {
 	// convert path to /
@@ -229,6 +253,21 @@ func ensureDirExists(filePath string) error {
 	return nil
 }
 
+// formatValues formats each value with the given verb and joins them with a space
+func formatValues(values []uint8, verb string) string {
+	parts := make([]string, len(values))
+	for i, value := range values {
+		parts[i] = fmt.Sprintf(verb, value)
+	}
+	return strings.Join(parts, " ")
+}
+
+// writeAGDLine writes an AGD source line with the directive padded to the data column
+func writeAGDLine(f io.Writer, directive string, data string) error {
+	_, err := fmt.Fprintf(f, "%-16s%s\n", directive, data)
+	return err
+}
+
 // addFileToTar adds a file to the tar archive
 func AddFileToTar(tarWriter *tar.Writer, filePath string) error {
 	// Open the file to be added to the tar archive
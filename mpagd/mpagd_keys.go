@@ -2,9 +2,11 @@ package mpagd
 
 import (
 	"encoding/binary"
+	"fmt"
 	"io"
 	"regexp"
 	"strconv"
+	"strings"
 )
 
 // readKeys reads the key data from the provided reader and updates the APJFile's Keys field.
@@ -59,3 +61,21 @@ func (apj *APJFile) writeKeys(f io.Writer) error {
 	}
 	return nil
 }
+
+// exportKeys writes the DEFINECONTROLS directive to the given writer.
+// Printable keys are written as quoted characters, all others as numbers.
+func (apj *APJFile) exportKeys(f io.Writer) error {
+	keys := make([]string, len(apj.Keys))
+	for i, key := range apj.Keys {
+		if key > 32 && key < 127 && key != '\'' {
+			keys[i] = fmt.Sprintf("'%c'", key)
+		} else {
+			keys[i] = fmt.Sprintf("%d", key)
+		}
+	}
+	if err := writeAGDLine(f, "DEFINECONTROLS", strings.Join(keys, " ")); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(f)
+	return err
+}
@@ -1,7 +1,12 @@
 package mpagd
 
 import (
+	"bytes"
 	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
 	"io"
 	"strings"
 )
@@ -46,12 +51,19 @@ func (apj *APJFile) MapDefault() {
 	}
 }
 
+// ImportMap parses a MAP/ENDMAP section, checking that every row has the declared width.
+// The imported map replaces the map of the project.
 func (apj *APJFile) ImportMap(lines []string) error {
+	return apj.importMap(lines, 0)
+}
+
+// importMap imports a MAP/ENDMAP section whose screens are appended after screenOffSet screens.
+// When screens were appended and the project already has screens on its map, the imported map
+// is appended to it, see appendMap. Otherwise it replaces the map of the project.
+func (apj *APJFile) importMap(lines []string, screenOffSet uint8) error {
 	// Initialize variables for map properties
 	var mapWidth uint8
 	var startScreen uint8
-	var startRow uint8
-	var startColumn uint8
 	var mapLayout [][]uint8
 	isMapSection := false
 
@@ -68,8 +80,10 @@ func (apj *APJFile) ImportMap(lines []string) error {
 		// Check for the start of the MAP section
 		if strings.HasPrefix(line, "MAP") {
 			isMapSection = true
-			line = strings.ReplaceAll(line, "MAP", "")
-			line = strings.TrimSpace(line)
+			line = strings.TrimSpace(strings.TrimPrefix(line, "MAP"))
+			if line == "" {
+				continue
+			}
 		}
 
 		// Process lines within the MAP section
@@ -77,18 +91,20 @@ func (apj *APJFile) ImportMap(lines []string) error {
 			// Parse the map width
 			if strings.HasPrefix(line, "WIDTH") {
 				parts := strings.Fields(line)
-				if len(parts) == 2 {
-					mapWidth = uint8(strUint8(parts[1]))
+				if len(parts) != 2 {
+					return fmt.Errorf("invalid map width: %s", line)
 				}
+				mapWidth = strUint8(parts[1])
 				continue
 			}
 
 			// Parse the start screen
 			if strings.HasPrefix(line, "STARTSCREEN") {
 				parts := strings.Fields(line)
-				if len(parts) == 2 {
-					startScreen = uint8(strUint8(parts[1]))
+				if len(parts) != 2 {
+					return fmt.Errorf("invalid map start screen: %s", line)
 				}
+				startScreen = strUint8(parts[1])
 				continue
 			}
 
@@ -100,41 +116,137 @@ func (apj *APJFile) ImportMap(lines []string) error {
 
 			// Parse map layout rows
 			rowValues := strings.Fields(line)
+			if mapWidth == 0 {
+				return fmt.Errorf("map WIDTH must be set before the map rows")
+			}
+			if len(rowValues) != int(mapWidth) {
+				return fmt.Errorf("map row %d has %d screens, expected WIDTH %d", len(mapLayout), len(rowValues), mapWidth)
+			}
 			row := make([]uint8, len(rowValues))
 			for i, value := range rowValues {
-				row[i] = uint8(strUint8(value))
+				row[i] = strUint8(value)
 			}
 			mapLayout = append(mapLayout, row)
-			continue
 		}
 	}
 
-	// Find the position of the start screen in the map layout
-	for i, row := range mapLayout {
-		for j, value := range row {
-			if value == startScreen {
-				startRow = uint8(i)
-				startColumn = uint8(j)
-				break
-			}
-		}
+	if len(mapLayout) == 0 {
+		return fmt.Errorf("map has no rows")
 	}
 
-	// Update the APJFile map properties
-	apj.Map.Width = mapWidth
-	apj.Map.Height = uint8(len(mapLayout))
-	apj.Map.StartRow = startRow
-	apj.Map.StartColumn = startColumn
-	apj.Map.StartScreen = startScreen
-	apj.Map.Map = mapLayout
+	imported := Map{Width: mapWidth, Height: uint8(len(mapLayout)), StartScreen: startScreen, Map: mapLayout}
+	if err := imported.remapScreens(screenOffSet); err != nil {
+		return err
+	}
+
+	if screenOffSet > 0 && apj.mapHasScreens() {
+		if err := apj.appendMap(imported); err != nil {
+			return err
+		}
+	} else {
+		// Update the APJFile map properties
+		apj.Map = imported
+		if !apj.setMapStart() {
+			LogMessage("ImportMap", fmt.Sprintf("Start screen %d is not on the map", imported.StartScreen), "warning", apj.noColor)
+		}
+	}
 
 	// Mark the map state as initialized
 	apj.State.Map = true
+	return nil
+}
 
-	// Return nil to indicate success
+// mapHasScreens returns true when any cell of the map holds a screen.
+func (apj *APJFile) mapHasScreens() bool {
+	for _, row := range apj.Map.Map {
+		for _, value := range row {
+			if value != 255 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// appendMap places a map to the right of the existing map, with an empty column between them
+// so the rooms of the two maps are not joined. The start screen of the existing map is kept.
+func (apj *APJFile) appendMap(other Map) error {
+	column := int(apj.Map.Width) + 1
+	width := column + int(other.Width)
+	if width > 255 {
+		return fmt.Errorf("appending the map would make it %d screens wide, the maximum is 255", width)
+	}
+	height := max(len(apj.Map.Map), len(other.Map))
+	merged := make([][]uint8, height)
+	for y := range merged {
+		merged[y] = bytes.Repeat([]uint8{255}, width)
+		if y < len(apj.Map.Map) {
+			copy(merged[y], apj.Map.Map[y])
+		}
+		if y < len(other.Map) {
+			copy(merged[y][column:], other.Map[y])
+		}
+	}
+	apj.Map.Map = merged
+	apj.Map.Width = uint8(width)
+	apj.Map.Height = uint8(height)
+	LogMessage("ImportMap", fmt.Sprintf("Map appended to the existing map from column %d", column), "info", apj.noColor)
+	return nil
+}
+
+// setMapStart sets the start row and column to the first map cell holding the start screen.
+// It returns false when the start screen is not on the map.
+func (apj *APJFile) setMapStart() bool {
+	for y, row := range apj.Map.Map {
+		for x, value := range row {
+			if value == apj.Map.StartScreen {
+				apj.Map.StartRow = uint8(y)
+				apj.Map.StartColumn = uint8(x)
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// remapScreens adds the screen offset to every screen on the map, used when screens are appended.
+// Screens are numbered up to 254 on the map, 255 is an empty cell.
+func (m *Map) remapScreens(screenOffSet uint8) error {
+	if int(m.StartScreen)+int(screenOffSet) > 254 {
+		return fmt.Errorf("start screen %d would become screen %d, the map holds screens up to 254", m.StartScreen, int(m.StartScreen)+int(screenOffSet))
+	}
+	for y, row := range m.Map {
+		for x, value := range row {
+			if value == 255 {
+				continue // skip empty map cells
+			}
+			if int(value)+int(screenOffSet) > 254 {
+				return fmt.Errorf("map cell %d,%d screen %d would become screen %d, the map holds screens up to 254", x, y, value, int(value)+int(screenOffSet))
+			}
+			m.Map[y][x] = value + screenOffSet
+		}
+	}
+	m.StartScreen += screenOffSet
 	return nil
 }
 
+// exportMap writes the MAP section to the given writer.
+func (apj *APJFile) exportMap(f io.Writer) error {
+	if err := writeAGDLine(f, "MAP", fmt.Sprintf("WIDTH %d", apj.Map.Width)); err != nil {
+		return err
+	}
+	if err := writeAGDLine(f, "", fmt.Sprintf("STARTSCREEN %d", apj.Map.StartScreen)); err != nil {
+		return err
+	}
+	for _, row := range apj.Map.Map {
+		if err := writeAGDLine(f, "", formatValues(row, "%3d")); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(f, "ENDMAP\n\n")
+	return err
+}
+
 // readMap reads map data
 func (apj *APJFile) readMap(f io.Reader) error {
 	if err := binary.Read(f, binary.LittleEndian, &apj.Map.Height); err != nil {
@@ -160,6 +272,13 @@ func (apj *APJFile) readMap(f io.Reader) error {
 	}
 	apj.State.Map = true
 	apj.Map.Map = mapData
+
+	// The start screen is not stored, it is the screen at the start row and column
+	if int(apj.Map.StartRow) < len(mapData) && int(apj.Map.StartColumn) < len(mapData[apj.Map.StartRow]) {
+		if screen := mapData[apj.Map.StartRow][apj.Map.StartColumn]; screen != 255 {
+			apj.Map.StartScreen = screen
+		}
+	}
 	return nil
 }
 
@@ -183,3 +302,117 @@ func (apj *APJFile) writeMap(f io.Writer) error {
 	}
 	return nil
 }
+
+// MapRenderOptions are the extras RenderMapToBitmap draws on top of the screens.
+type MapRenderOptions struct {
+	Labels bool // Write the screen number in the top left corner of each screen
+	Grid   bool // Draw lines between the screens
+	Mono   bool // Draw ink in white and paper in black, and empty map cells on black, for output without colours
+}
+
+// Colours used by RenderMapToBitmap for the parts of the map that are not screens.
+var (
+	mapGridColour    = color.RGBA{96, 96, 96, 255}
+	mapEmptyColour   = color.RGBA{32, 32, 32, 255}
+	mapHatchColour   = color.RGBA{64, 64, 64, 255}
+	mapMissingColour = color.RGBA{192, 0, 0, 255}
+	mapStartColour   = color.RGBA{255, 255, 0, 255}
+)
+
+// RenderMapToBitmap renders every screen of the map in its place on the map and saves it as a
+// PNG file. Empty map cells are hatched in grey, cells using a screen that does not exist are
+// hatched in red, and the start screen is outlined in yellow.
+func (apj *APJFile) RenderMapToBitmap(filePath string, options MapRenderOptions) error {
+	img, err := apj.RenderMapImage(options)
+	if err != nil {
+		return err
+	}
+	return writePNG(filePath, img)
+}
+
+// RenderMapToTerminal writes the map to the terminal in its Spectrum colours,
+// or with the ink in the terminal's own colour when colour is false.
+func (apj *APJFile) RenderMapToTerminal(w io.Writer, options MapRenderOptions, colour bool) error {
+	options.Mono = options.Mono || !colour
+	img, err := apj.RenderMapImage(options)
+	if err != nil {
+		return err
+	}
+	return WriteTerminalImage(w, img, colour)
+}
+
+// RenderMapImage draws every screen of the map into an image, as RenderMapToBitmap describes.
+func (apj *APJFile) RenderMapImage(options MapRenderOptions) (*image.RGBA, error) {
+	rows := len(apj.Map.Map)
+	columns := 0
+	for _, row := range apj.Map.Map {
+		columns = max(columns, len(row))
+	}
+	if rows == 0 || columns == 0 {
+		return nil, fmt.Errorf("the project has no map")
+	}
+
+	// With the grid, each screen is followed by a one pixel line and the map has a border
+	gap := 0
+	if options.Grid {
+		gap = 1
+	}
+	width, height := int(apj.Windows.Width)*8, int(apj.Windows.Height)*8
+	img := image.NewRGBA(image.Rect(0, 0, columns*(width+gap)+gap, rows*(height+gap)+gap))
+	draw.Draw(img, img.Bounds(), &image.Uniform{mapGridColour}, image.Point{}, draw.Src)
+	var empty color.Color = mapEmptyColour
+	if options.Mono {
+		empty = color.Black
+	}
+
+	for y := 0; y < rows; y++ {
+		for x := 0; x < columns; x++ {
+			x0, y0 := gap+x*(width+gap), gap+y*(height+gap)
+			screen := uint8(255)
+			if x < len(apj.Map.Map[y]) {
+				screen = apj.Map.Map[y][x]
+			}
+			switch {
+			case screen == 255:
+				drawHatch(img, image.Rect(x0, y0, x0+width, y0+height), mapHatchColour, empty)
+			case int(screen) >= len(apj.Screens):
+				drawHatch(img, image.Rect(x0, y0, x0+width, y0+height), mapMissingColour, empty)
+			default:
+				if err := apj.drawScreen(img, screen, x0, y0, options.Mono); err != nil {
+					return nil, err
+				}
+			}
+			if options.Labels && screen != 255 {
+				apj.drawText(img, x0+1, y0+1, fmt.Sprintf("%d", screen), color.White, color.Black)
+			}
+		}
+	}
+
+	// Outline the start screen
+	start := image.Rect(0, 0, width, height).Add(image.Pt(gap+int(apj.Map.StartColumn)*(width+gap), gap+int(apj.Map.StartRow)*(height+gap)))
+	for i := 0; i < 2; i++ {
+		outline := start.Inset(i)
+		for x := outline.Min.X; x < outline.Max.X; x++ {
+			img.Set(x, outline.Min.Y, mapStartColour)
+			img.Set(x, outline.Max.Y-1, mapStartColour)
+		}
+		for y := outline.Min.Y; y < outline.Max.Y; y++ {
+			img.Set(outline.Min.X, y, mapStartColour)
+			img.Set(outline.Max.X-1, y, mapStartColour)
+		}
+	}
+	return img, nil
+}
+
+// drawHatch fills the rectangle with diagonal lines of the colour on the background colour.
+func drawHatch(img *image.RGBA, rect image.Rectangle, lines, background color.Color) {
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			if (x-rect.Min.X+y-rect.Min.Y)%8 == 0 {
+				img.Set(x, y, lines)
+			} else {
+				img.Set(x, y, background)
+			}
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package mpagd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Message represents a single DEFINEMESSAGES entry.
+type Message struct {
+	ID   uint8  `json:"id"`   // Message number used by the MESSAGE command
+	Text string `json:"text"` // Message text without the surrounding quotes
+}
+
+// MessagesFileExt is the extension of the file that stores the project messages.
+// MPAGD does not keep the messages in the APJ file, so they are stored next to it.
+const MessagesFileExt = ".msg"
+
+// MessagesInit initializes the Messages slice. If overwrite is true, it clears the existing messages.
+func (apj *APJFile) MessagesInit(overwrite bool) {
+	if apj.Messages == nil || overwrite {
+		apj.Messages = make([]Message, 0)
+	}
+}
+
+// ImportMessages parses quoted message lines with an optional trailing comment and appends them.
+func (apj *APJFile) ImportMessages(lines []string) error {
+	pattern := regexp.MustCompile(`^"([^"]*)"\s*(;.*)?$`)
+	for _, v := range lines {
+		line := strings.TrimSpace(strings.ReplaceAll(v, "DEFINEMESSAGES", ""))
+		if line == "" || strings.HasPrefix(line, ";") {
+			continue
+		}
+		match := pattern.FindStringSubmatch(line)
+		if match == nil {
+			return fmt.Errorf("invalid message line: %s", line)
+		}
+		apj.Messages = append(apj.Messages, Message{
+			ID:   uint8(len(apj.Messages)),
+			Text: match[1],
+		})
+		apj.State.Messages = true
+	}
+	return nil
+}
+
+// exportMessages writes the DEFINEMESSAGES directive with one quoted message per line.
+func (apj *APJFile) exportMessages(f io.Writer) error {
+	if len(apj.Messages) == 0 {
+		return nil
+	}
+	if _, err := fmt.Fprintln(f, "DEFINEMESSAGES"); err != nil {
+		return err
+	}
+	for _, message := range apj.Messages {
+		if _, err := fmt.Fprintf(f, "%-16s ;%d\n", fmt.Sprintf("\"%s\"", message.Text), message.ID); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintln(f)
+	return err
+}
+
+// MessagesFilePath returns the path of the messages file for the given project file.
+func MessagesFilePath(projectFile string) string {
+	return strings.TrimSuffix(projectFile, filepath.Ext(projectFile)) + MessagesFileExt
+}
+
+// readMessages reads the messages file next to the project file, if it exists.
+func (apj *APJFile) readMessages() error {
+	file, err := os.Open(MessagesFilePath(apj.FilePath))
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	apj.MessagesInit(true)
+	apj.State.Messages = true
+	return apj.ImportMessages(lines)
+}
+
+// writeMessages writes the messages file next to the given project file.
+func (apj *APJFile) writeMessages(projectFile string) error {
+	if !apj.State.Messages {
+		return nil
+	}
+	file, err := os.Create(MessagesFilePath(projectFile))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return apj.exportMessages(file)
+}
+
+// codeMessagePatterns match the messages displayed by the event code.
+var codeMessagePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\bMESSAGE\s+(\w+)`),
+}
+
+// renumberMessages updates the message IDs to match their position.
+func (apj *APJFile) renumberMessages() {
+	for i := range apj.Messages {
+		apj.Messages[i].ID = uint8(i)
+	}
+	apj.State.Messages = true
+}
+
+// remapMessages changes the MESSAGE statements in the event code from their old message to
+// mapping[old message], and writes the code to the event files of the project at codePath.
+// It returns the MESSAGE statements it can not renumber, those using a variable and those
+// using a deleted message.
+func (apj *APJFile) remapMessages(mapping []int, codePath string) ([]CodeReference, error) {
+	events, err := apj.readEventLines()
+	if err != nil {
+		return nil, err
+	}
+	unchanged := make([]CodeReference, 0)
+	for _, reference := range findCodeReferences(events, codeMessagePatterns) {
+		if messageID, ok := codeNumber(reference.Value); !ok || (messageID < len(mapping) && mapping[messageID] < 0) {
+			unchanged = append(unchanged, reference)
+		}
+	}
+	codeMappings := make(map[*regexp.Regexp][]int)
+	for _, pattern := range codeMessagePatterns {
+		codeMappings[pattern] = mapping
+	}
+	return unchanged, apj.remapEventCode(codePath, codeMappings)
+}
+
+// AddMessage inserts a message at the given index, or appends it when index is negative.
+// The MESSAGE statements in the event code are renumbered and written to the event files of
+// the project at codePath. It returns the MESSAGE statements that use a variable.
+func (apj *APJFile) AddMessage(text string, index int, codePath string) (uint8, []CodeReference, error) {
+	if len(apj.Messages) >= 255 {
+		return 0, nil, fmt.Errorf("maximum number of messages reached")
+	}
+	if index < 0 || index > len(apj.Messages) {
+		index = len(apj.Messages)
+	}
+	mapping := make([]int, len(apj.Messages))
+	for i := range mapping {
+		mapping[i] = i
+		if i >= index {
+			mapping[i] = i + 1
+		}
+	}
+	apj.Messages = append(apj.Messages[:index], append([]Message{{Text: text}}, apj.Messages[index:]...)...)
+	apj.renumberMessages()
+	unchanged, err := apj.remapMessages(mapping, codePath)
+	return uint8(index), unchanged, err
+}
+
+// EditMessage replaces the text of the message with the given ID.
+func (apj *APJFile) EditMessage(messageID int, text string) error {
+	if messageID < 0 || messageID >= len(apj.Messages) {
+		return fmt.Errorf("message index out of range: %d", messageID)
+	}
+	apj.Messages[messageID].Text = text
+	apj.State.Messages = true
+	return nil
+}
+
+// DeleteMessage removes the message with the given ID and renumbers the rest, along with the
+// MESSAGE statements in the event code, which are written to the event files of the project at
+// codePath. It returns the MESSAGE statements that use a variable or the deleted message.
+func (apj *APJFile) DeleteMessage(messageID int, codePath string) ([]CodeReference, error) {
+	if messageID < 0 || messageID >= len(apj.Messages) {
+		return nil, fmt.Errorf("message index out of range: %d", messageID)
+	}
+	mapping := deleteMapping(len(apj.Messages), []int{messageID})
+	apj.Messages = append(apj.Messages[:messageID], apj.Messages[messageID+1:]...)
+	apj.renumberMessages()
+	return apj.remapMessages(mapping, codePath)
+}
+
+// ReorderMessages reorders the messages, order[newIndex] being the old message ID, and renumbers
+// the MESSAGE statements in the event code, which are written to the event files of the project
+// at codePath. It returns the MESSAGE statements that use a variable.
+func (apj *APJFile) ReorderMessages(order []int, codePath string) ([]CodeReference, error) {
+	if len(order) != len(apj.Messages) {
+		return nil, fmt.Errorf("order length does not match the number of messages")
+	}
+	used := make(map[int]bool)
+	mapping := make([]int, len(apj.Messages))
+	newMessages := make([]Message, len(apj.Messages))
+	for newIndex, oldIndex := range order {
+		if oldIndex < 0 || oldIndex >= len(apj.Messages) || used[oldIndex] {
+			return nil, fmt.Errorf("invalid message index in order: %d", oldIndex)
+		}
+		used[oldIndex] = true
+		mapping[oldIndex] = newIndex
+		newMessages[newIndex] = apj.Messages[oldIndex]
+	}
+	apj.Messages = newMessages
+	apj.renumberMessages()
+	return apj.remapMessages(mapping, codePath)
+}
@@ -2,11 +2,16 @@ package mpagd
 
 import (
 	"encoding/binary"
+	"fmt"
 	"io"
 	"regexp"
 	"strings"
 )
 
+// ObjectNoRoom is the room of an object that is not placed on any screen, e.g. one the player
+// starts with or one that is created by the event code. Objects in a deleted screen are moved to it.
+const ObjectNoRoom uint8 = 254
+
 // Object represents the structure of an object with various platform-specific data.
 type Object struct {
 	ID         uint8   // Unique identifier for the object
@@ -42,10 +47,12 @@ func (apj *APJFile) ObjectDefault() {
 	}
 }
 
-// ImportObjects parses object definitions and data from a list of strings.
+// ImportObjects parses an object definition and its data from a list of strings and appends it
+// to the objects. It is called once for each DEFINEOBJECT, ObjectInit clears the objects first
+// when they are overwritten.
 func (apj *APJFile) ImportObjects(lines []string) error {
 	pattern := regexp.MustCompile(`DEFINEOBJECT\s+(\d+)\s+(\d+)\s+(\d+)\s+(\d+)`)
-	var objects []Object
+	objects := apj.Objects
 	var currentData []uint8
 	isData := false
 
@@ -82,6 +89,30 @@ func (apj *APJFile) ImportObjects(lines []string) error {
 	return nil
 }
 
+// exportObjects writes a DEFINEOBJECT directive for each object to the given writer.
+// The first four Spectrum bytes hold the attribute, room, vertical and horizontal position of the object.
+func (apj *APJFile) exportObjects(f io.Writer) error {
+	for _, obj := range apj.Objects {
+		if len(obj.Spectrum) < 4 {
+			continue
+		}
+		if err := writeAGDLine(f, "DEFINEOBJECT", formatValues(obj.Spectrum[:4], "%d")); err != nil {
+			return err
+		}
+		data := obj.Spectrum[4:]
+		for i := 0; i < len(data); i += 16 {
+			end := min(i+16, len(data))
+			if err := writeAGDLine(f, "", formatValues(data[i:end], "%d")); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintln(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // ObjectInit initializes or resets the Objects slice based on the overwrite flag.
 func (apj *APJFile) ObjectInit(overwrite bool) {
 	if len(apj.Objects) == 0 || overwrite {
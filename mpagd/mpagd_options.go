@@ -12,6 +12,7 @@ type ImportOptions struct {
 	owMaps           bool // Overwrite maps
 	owFonts          bool // Overwrite fonts
 	owULAPalette     bool // Overwrite ULA palette
+	owMessages       bool // Overwrite messages
 	ignoreWindow     bool // Ignore window
 	ignoreKeys       bool // Ignore keys
 	ignoreBlocks     bool // Ignore blocks
@@ -21,6 +22,7 @@ type ImportOptions struct {
 	ignoreMaps       bool // Ignore maps
 	ignoreFonts      bool // Ignore fonts
 	ignoreULAPalette bool // Ignore ULA palette
+	ignoreMessages   bool // Ignore messages
 }
 
 // CreateImportOptions initializes an ImportOptions instance with default values (all false).
@@ -35,6 +37,7 @@ func CreateImportOptions() ImportOptions {
 		owMaps:           false,
 		owFonts:          false,
 		owULAPalette:     false,
+		owMessages:       false,
 		ignoreWindow:     false,
 		ignoreKeys:       false,
 		ignoreBlocks:     false,
@@ -43,11 +46,12 @@ func CreateImportOptions() ImportOptions {
 		ignoreScreens:    false,
 		ignoreMaps:       false,
 		ignoreULAPalette: false,
+		ignoreMessages:   false,
 	}
 }
 
 // SetOwOptions sets the overwrite options for all components.
-func (o *ImportOptions) SetOwOptions(owWindow, owKeys, owBlocks, owSprites, owObjects, owScreens, owMaps, owFonts, owULAPalette bool) {
+func (o *ImportOptions) SetOwOptions(owWindow, owKeys, owBlocks, owSprites, owObjects, owScreens, owMaps, owFonts, owULAPalette, owMessages bool) {
 	o.owBlocks = owBlocks
 	o.owFonts = owFonts
 	o.owMaps = owMaps
@@ -57,30 +61,31 @@ func (o *ImportOptions) SetOwOptions(owWindow, owKeys, owBlocks, owSprites, owOb
 	o.owULAPalette = owULAPalette
 	o.owKeys = owKeys
 	o.owWindow = owWindow
+	o.owMessages = owMessages
 }
 
 // SetOwOptionsTrue enables overwrite for all components.
 func (o *ImportOptions) SetOwOptionsTrue() {
-	o.SetOwOptions(true, true, true, true, true, true, true, true, true)
+	o.SetOwOptions(true, true, true, true, true, true, true, true, true, true)
 }
 
 // SetOwOptionsFalse disables overwrite for all components.
 func (o *ImportOptions) SetOwOptionsFalse() {
-	o.SetOwOptions(false, false, false, false, false, false, false, false, false)
+	o.SetOwOptions(false, false, false, false, false, false, false, false, false, false)
 }
 
 // SetIgnoreOptionsTrue enables ignore for all components.
 func (o *ImportOptions) SetIgnoreOptionsTrue() {
-	o.SetIgnoreOptions(true, true, true, true, true, true, true, true, true)
+	o.SetIgnoreOptions(true, true, true, true, true, true, true, true, true, true)
 }
 
 // SetIgnoreOptionsFalse disables ignore for all components.
 func (o *ImportOptions) SetIgnoreOptionsFalse() {
-	o.SetIgnoreOptions(false, false, false, false, false, false, false, false, false)
+	o.SetIgnoreOptions(false, false, false, false, false, false, false, false, false, false)
 }
 
 // SetIgnoreOptions sets the ignore options for all components.
-func (o *ImportOptions) SetIgnoreOptions(ignoreWindow, ignoreKeys, ignoreBlocks, ignoreSprites, ignoreObjects, ignoreScreens, ignoreMaps, ignoreFonts, ignoreULAPalette bool) {
+func (o *ImportOptions) SetIgnoreOptions(ignoreWindow, ignoreKeys, ignoreBlocks, ignoreSprites, ignoreObjects, ignoreScreens, ignoreMaps, ignoreFonts, ignoreULAPalette, ignoreMessages bool) {
 	o.ignoreBlocks = ignoreBlocks
 	o.ignoreFonts = ignoreFonts
 	o.ignoreMaps = ignoreMaps
@@ -90,4 +95,5 @@ func (o *ImportOptions) SetIgnoreOptions(ignoreWindow, ignoreKeys, ignoreBlocks,
 	o.ignoreULAPalette = ignoreULAPalette
 	o.ignoreKeys = ignoreKeys
 	o.ignoreWindow = ignoreWindow
+	o.ignoreMessages = ignoreMessages
 }
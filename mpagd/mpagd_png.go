@@ -0,0 +1,211 @@
+package mpagd
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PNGCellIssue describes an image cell that could not be converted exactly.
+type PNGCellIssue struct {
+	Cell    int    // Index of the cell in reading order
+	Column  int    // Column of the cell in the image
+	Row     int    // Row of the cell in the image
+	Colours int    // Number of Spectrum colours found in the cell
+	Message string // Description of the problem
+}
+
+// spectrumColour is a Spectrum colour with its ink/paper index and brightness.
+type spectrumColour struct {
+	Index  uint8
+	Bright bool
+}
+
+// ReadPNG reads a PNG image from the given file path.
+func ReadPNG(filePath string) (image.Image, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	img, err := png.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode PNG file: %w", err)
+	}
+	return img, nil
+}
+
+// writePNG encodes the image to a PNG file at the given path.
+func writePNG(filePath string, img image.Image) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return png.Encode(file, img)
+}
+
+// ListPNGFiles returns the PNG file at the given path, or the PNG files in the given directory.
+// Files in a directory are sorted by the number in their name, so sprite_10.png follows sprite_9.png.
+func ListPNGFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(path, "*.png"))
+	if err != nil {
+		return nil, err
+	}
+	numberPattern := regexp.MustCompile(`(\d+)\.png$`)
+	fileNumber := func(name string) int {
+		if match := numberPattern.FindStringSubmatch(strings.ToLower(name)); match != nil {
+			number, _ := strconv.Atoi(match[1])
+			return number
+		}
+		return -1
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		if fileNumber(files[i]) != fileNumber(files[j]) {
+			return fileNumber(files[i]) < fileNumber(files[j])
+		}
+		return files[i] < files[j]
+	})
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no PNG files found in %s", path)
+	}
+	return files, nil
+}
+
+// CountPNGCells returns the number of columns and rows of cells in an image.
+// gap is the number of pixels between the cells, as written by the render commands.
+func CountPNGCells(img image.Image, size, gap int) (int, int) {
+	bounds := img.Bounds()
+	columns := (bounds.Dx() + gap) / (size + gap)
+	rows := (bounds.Dy() + gap) / (size + gap)
+	return columns, rows
+}
+
+// nearestSpectrumColour matches a colour against the palette used by SpectrumAttrToColors.
+func nearestSpectrumColour(c color.Color) spectrumColour {
+	r, g, b, _ := c.RGBA()
+	best := spectrumColour{}
+	bestDist := uint64(1<<63 - 1)
+	for attr := uint8(0); attr < 16; attr++ {
+		bright := attr >= 8
+		ink := attr & 0x07
+		brightBit := uint8(0)
+		if bright {
+			brightBit = 0x40
+		}
+		pc, _ := SpectrumAttrToColors(brightBit | ink)
+		pr, pg, pb, _ := pc.RGBA()
+		dist := sqDiff(r>>8, pr>>8) + sqDiff(g>>8, pg>>8) + sqDiff(b>>8, pb>>8)
+		if dist < bestDist {
+			bestDist = dist
+			best = spectrumColour{Index: ink, Bright: bright && ink != 0}
+		}
+	}
+	return best
+}
+
+// sqDiff returns the squared difference of two colour components.
+func sqDiff(a, b uint32) uint64 {
+	d := int64(a) - int64(b)
+	return uint64(d * d)
+}
+
+// cellToSpectrum converts an 8x8 image cell into 8 bitmap bytes and an attribute byte.
+// The most used colour becomes the paper, the other one the ink.
+// It returns an issue when the cell can not be shown with a single Spectrum attribute.
+func cellToSpectrum(img image.Image, x0, y0 int) ([]uint8, *PNGCellIssue) {
+	pixels := make([][]spectrumColour, 8)
+	counts := make(map[spectrumColour]int)
+	for y := 0; y < 8; y++ {
+		pixels[y] = make([]spectrumColour, 8)
+		for x := 0; x < 8; x++ {
+			c := nearestSpectrumColour(img.At(x0+x, y0+y))
+			pixels[y][x] = c
+			counts[c]++
+		}
+	}
+
+	// Sort the colours by use, the darkest colour first on a tie
+	colours := make([]spectrumColour, 0, len(counts))
+	for c := range counts {
+		colours = append(colours, c)
+	}
+	sort.Slice(colours, func(i, j int) bool {
+		if counts[colours[i]] != counts[colours[j]] {
+			return counts[colours[i]] > counts[colours[j]]
+		}
+		return colours[i].Index < colours[j].Index
+	})
+
+	paper := colours[0]
+	ink := spectrumColour{Index: 7}
+	if paper.Index == 7 {
+		ink = spectrumColour{Index: 0}
+	}
+	if len(colours) > 1 {
+		ink = colours[1]
+	}
+
+	var issue *PNGCellIssue
+	if len(colours) > 2 {
+		issue = &PNGCellIssue{Colours: len(colours), Message: fmt.Sprintf("uses %d colours", len(colours))}
+	} else if len(colours) == 2 && paper.Index != 0 && ink.Index != 0 && paper.Bright != ink.Bright {
+		issue = &PNGCellIssue{Colours: len(colours), Message: "mixes bright and normal colours"}
+	}
+
+	// Build the bitmap, any colour other than the paper is drawn as ink
+	data := make([]uint8, 9)
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			if pixels[y][x] != paper {
+				data[y] |= 1 << (7 - x)
+			}
+		}
+	}
+
+	attr := paper.Index<<3 | ink.Index
+	if paper.Bright || ink.Bright {
+		attr |= 0x40
+	}
+	data[8] = attr
+	return data, issue
+}
+
+// cellToBitmap converts a square image cell into a monochrome bitmap, one bit per pixel
+// and size/8 bytes per row. Black and transparent pixels are clear, any other colour is set.
+func cellToBitmap(img image.Image, x0, y0, size int) []uint8 {
+	bytesPerRow := size / 8
+	data := make([]uint8, size*bytesPerRow)
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			if nearestSpectrumColour(img.At(x0+x, y0+y)).Index != 0 {
+				data[y*bytesPerRow+x/8] |= 1 << (7 - x%8)
+			}
+		}
+	}
+	return data
+}
+
+// blockSignature returns the Spectrum bitmap and attribute bytes of a block, so blocks only
+// match when their data is the same.
+func blockSignature(data []uint8) string {
+	if len(data) < 9 {
+		return ""
+	}
+	return string(data[:9])
+}
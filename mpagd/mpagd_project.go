@@ -120,6 +120,7 @@ func (apj *APJFile) DisplayStats() {
 	fmt.Printf("Objects: %d\n", len(apj.Objects))
 	fmt.Printf("Maps: %d\n", len(apj.Map.Map))
 	fmt.Printf("Fonts: %d\n", len(apj.Fonts))
+	fmt.Printf("Messages: %d\n", len(apj.Messages))
 
 }
 
@@ -209,6 +210,7 @@ type ProjectInfo struct {
 	Fonts      int          `json:"fonts"`
 	Variables  []Variables  `json:"variables"`
 	SpriteType []SpriteType `json:"sprite_type"`
+	Messages   []Message    `json:"messages"`
 }
 
 func (apj *APJFile) BuildProjectInfoJson() (string, error) {
@@ -244,6 +246,7 @@ func (apj *APJFile) BuildProjectInfoJson() (string, error) {
 		Objects:    len(apj.Objects),
 		Maps:       len(apj.Map.Map),
 		Fonts:      len(apj.Fonts),
+		Messages:   apj.Messages,
 		Variables:  ExtractVariablesFromCodeFiles(directoryPath),
 		SpriteType: make([]SpriteType, 0),
 		Name:       name,
@@ -290,7 +293,10 @@ func (apj *APJFile) BuildProjectInfoJson() (string, error) {
 		// Collect block types
 		for _, row := range screen.ScreenData {
 			for _, blockID := range row {
-				blockType := GetBlockTypeByTypeID(apj.Blocks[blockID].Type)
+				blockType := "UNKNOWN" // screen cells can refer to blocks that do not exist
+				if int(blockID) < len(apj.Blocks) {
+					blockType = GetBlockTypeByTypeID(apj.Blocks[blockID].Type)
+				}
 				if _, exists := blockTypes[blockID]; !exists {
 					blockTypesDo := BlockTypesDocInfo{
 						ID:   blockID, // I
@@ -371,7 +377,7 @@ func (apj *APJFile) BuildProjectInfoJson() (string, error) {
 		}
 		for _, st := range apj.Screens {
 			screenBMP := filepath.Join(screenDir, fmt.Sprintf("screen_%d.png", st.ScreenID))
-			err := apj.RenderScreenToBitmap(st.ScreenID, screenBMP)
+			err := apj.RenderScreenToBitmap(st.ScreenID, screenBMP, ScreenRenderOptions{})
 			if err != nil {
 				return "", fmt.Errorf("failed to render screens: %w", err)
 			}
@@ -623,6 +629,7 @@ func BuildProjectReadme(fileName string, projectInfoJson []byte) error {
 	buf.WriteString(fmt.Sprintf("- **Objects:** %d\n", data.Objects))
 	buf.WriteString(fmt.Sprintf("- **Maps:** %d\n", data.Maps))
 	buf.WriteString(fmt.Sprintf("- **Fonts:** %d\n", data.Fonts))
+	buf.WriteString(fmt.Sprintf("- **Messages:** %d\n", len(data.Messages)))
 	buf.WriteString("\n---\n\n")
 
 	buf.WriteString("## Block Types\n\n")
@@ -665,6 +672,12 @@ func BuildProjectReadme(fileName string, projectInfoJson []byte) error {
 	}
 	buf.WriteString("\n---\n\n")
 
+	buf.WriteString("## Messages\n\n")
+	for _, m := range data.Messages {
+		buf.WriteString(fmt.Sprintf("- **%d:** \"%s\"\n", m.ID, m.Text))
+	}
+	buf.WriteString("\n---\n\n")
+
 	buf.WriteString("## Screens\n\n")
 	for _, s := range data.Screens.Info {
 
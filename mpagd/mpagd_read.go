@@ -39,7 +39,9 @@ func (apj *APJFile) ReadAPJ() error {
 			return err
 		}
 	}
-	return nil
+
+	// Messages are not part of the APJ file, they are kept in their own file
+	return apj.readMessages()
 }
 
 // CreateBlank initializes a blank APJ file with default values.
@@ -68,7 +70,9 @@ func (apj *APJFile) CreateState() State {
 		SpriteInfo:     false,
 		Sprites:        false,
 		ULAPalette:     false,
+		Messages:       false,
 		BlocksOffSet:   uint8(0),
+		ScreensOffSet:  uint8(0),
 	}
 }
 
@@ -115,6 +119,7 @@ func (apj *APJFile) initStruct(overwrite bool) {
 	apj.ULAPaletteInit(overwrite)
 	apj.LivesScoreInit(overwrite)
 	apj.KeysInit(overwrite)
+	apj.MessagesInit(overwrite)
 }
 
 // ImportAGD imports data from an AGD file into the APJ file.
@@ -133,73 +138,95 @@ func (apj *APJFile) ImportAGD(agdFilePath string, options ImportOptions) error {
 	// Process each line in the AGD file.
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
+		err = nil
 
 		// Match and process specific AGD directives.
 		switch {
 		case strings.Contains(line, "DEFINEWINDOW") && !options.ignoreWindow:
 			lineBuf = append(lineBuf, line)
 			lineBufFunction = "DEFINEWINDOW"
-			apj.handleDirective(&lineBufFunction, line, &lineBuf, state, options, "DEFINEWINDOW", apj.WindowsInit, apj.importWindows, options.owWindow, &state.Windows)
+			err = apj.handleDirective(&lineBufFunction, line, &lineBuf, state, options, "DEFINEWINDOW", apj.WindowsInit, apj.importWindows, options.owWindow, &state.Windows)
 		case strings.Contains(line, "DEFINECONTROLS") && !options.ignoreKeys:
 			lineBuf = append(lineBuf, line)
 			lineBufFunction = "DEFINECONTROLS"
-			apj.handleDirective(&lineBufFunction, line, &lineBuf, state, options, "DEFINECONTROLS", apj.KeysInit, apj.importKeys, options.owKeys, &state.Keys)
+			err = apj.handleDirective(&lineBufFunction, line, &lineBuf, state, options, "DEFINECONTROLS", apj.KeysInit, apj.importKeys, options.owKeys, &state.Keys)
 		case strings.Contains(line, "DEFINEBLOCK") && !options.ignoreBlocks:
-			apj.handleBufferedDirective(&lineBufFunction, line, &lineBuf, state, options, "DEFINEBLOCK", apj.BlockInit, apj.ImportBlocks, options.owBlocks, &state.Blocks, &state.BlocksOffSet)
+			err = apj.handleBufferedDirective(&lineBufFunction, line, &lineBuf, state, options, "DEFINEBLOCK", apj.BlockInit, apj.ImportBlocks, options.owBlocks, &state.Blocks, &state.BlocksOffSet)
 		case strings.Contains(line, "DEFINESPRITE") && !options.ignoreSprites:
-			apj.handleBufferedDirective(&lineBufFunction, line, &lineBuf, state, options, "DEFINESPRITE", apj.SpriteInit, apj.ImportSprites, options.owSprites, &state.Sprites, nil)
+			err = apj.handleBufferedDirective(&lineBufFunction, line, &lineBuf, state, options, "DEFINESPRITE", apj.SpriteInit, apj.ImportSprites, options.owSprites, &state.Sprites, nil)
 		case strings.Contains(line, "DEFINEOBJECT") && !options.ignoreObjects:
-			apj.handleBufferedDirective(&lineBufFunction, line, &lineBuf, state, options, "DEFINEOBJECT", apj.ObjectInit, apj.ImportObjects, options.owObjects, &state.Objects, nil)
+			err = apj.handleBufferedDirective(&lineBufFunction, line, &lineBuf, state, options, "DEFINEOBJECT", apj.ObjectInit, apj.ImportObjects, options.owObjects, &state.Objects, nil)
 		case strings.Contains(line, "DEFINESCREEN") && !options.ignoreScreens:
-			apj.handleBufferedDirective(&lineBufFunction, line, &lineBuf, state, options, "DEFINESCREEN", apj.ScreensInit, apj.ImportScreens, options.owScreens, &state.Screens, nil)
+			err = apj.handleBufferedDirective(&lineBufFunction, line, &lineBuf, state, options, "DEFINESCREEN", apj.ScreensInit, apj.ImportScreens, options.owScreens, &state.Screens, &state.ScreensOffSet)
 		case strings.HasPrefix(line, "MAP") && !options.ignoreMaps:
-			apj.handleBufferedDirective(&lineBufFunction, line, &lineBuf, state, options, "MAP", apj.MapInit, nil, options.owMaps, &state.Map, nil)
+			err = apj.handleBufferedDirective(&lineBufFunction, line, &lineBuf, state, options, "MAP", apj.MapInit, apj.ImportMap, options.owMaps, &state.Map, nil)
 		case strings.HasPrefix(line, "DEFINEFONT") && !options.ignoreFonts:
-			apj.handleBufferedDirective(&lineBufFunction, line, &lineBuf, state, options, "DEFINEFONT", apj.FontInit, apj.ImportFont, options.owFonts, &state.Fonts, nil)
+			err = apj.handleBufferedDirective(&lineBufFunction, line, &lineBuf, state, options, "DEFINEFONT", apj.FontInit, apj.ImportFont, options.owFonts, &state.Fonts, nil)
 		case strings.HasPrefix(line, "DEFINEPALETTE") && !options.ignoreULAPalette:
-			apj.handleBufferedDirective(&lineBufFunction, line, &lineBuf, state, options, "DEFINEPALETTE", apj.ULAPaletteInit, apj.ImportULAPalette, options.owULAPalette, &state.ULAPalette, nil)
+			err = apj.handleBufferedDirective(&lineBufFunction, line, &lineBuf, state, options, "DEFINEPALETTE", apj.ULAPaletteInit, apj.ImportULAPalette, options.owULAPalette, &state.ULAPalette, nil)
+		case strings.HasPrefix(line, "DEFINEMESSAGES") && !options.ignoreMessages:
+			err = apj.handleBufferedDirective(&lineBufFunction, line, &lineBuf, state, options, "DEFINEMESSAGES", apj.MessagesInit, apj.ImportMessages, options.owMessages, &state.Messages, nil)
 		case strings.HasPrefix(line, "DEFINEMESSAGES"), strings.HasPrefix(line, "EVENT"):
-			apj.processData(&lineBufFunction, &lineBuf, state, options)
+			err = apj.processData(&lineBufFunction, &lineBuf, state, options)
 			lineBufFunction = ""
 		default:
 			if line != "" && !apj.checkIgnore(lineBufFunction, options) {
 				lineBuf = append(lineBuf, line)
 			} else {
-				apj.processData(&lineBufFunction, &lineBuf, state, options)
+				err = apj.processData(&lineBufFunction, &lineBuf, state, options)
 			}
 		}
+		if err != nil {
+			return err
+		}
 	}
 
 	if err := scanner.Err(); err != nil {
 		return fmt.Errorf("error reading AGD file: %w", err)
 	}
 
+	// Process the last section when the file does not end with a blank line
+	if err := apj.processData(&lineBufFunction, &lineBuf, state, options); err != nil {
+		return err
+	}
+
 	apj.initDefaults()
 	return nil
 }
 
 // handleDirective processes a single-line directive.
-func (apj *APJFile) handleDirective(lineBufFunction *string, line string, lineBuf *[]string, state State, options ImportOptions, directive string, initFunc func(bool), importFunc func(string) error, overwrite bool, stateField *bool) {
-	apj.processData(lineBufFunction, lineBuf, state, options)
+func (apj *APJFile) handleDirective(lineBufFunction *string, line string, lineBuf *[]string, state State, options ImportOptions, directive string, initFunc func(bool), importFunc func(string) error, overwrite bool, stateField *bool) error {
+	if err := apj.processData(lineBufFunction, lineBuf, state, options); err != nil {
+		return err
+	}
 	if !*stateField {
 		*stateField = true
 		initFunc(overwrite)
 	}
-	importFunc(line)
+	return importFunc(line)
 }
 
 // handleBufferedDirective processes a buffered directive.
-func (apj *APJFile) handleBufferedDirective(lineBufFunction *string, line string, lineBuf *[]string, state State, options ImportOptions, directive string, initFunc func(bool), importFunc func([]string) error, overwrite bool, stateField *bool, offsetField *uint8) {
-	apj.processData(lineBufFunction, lineBuf, state, options)
+func (apj *APJFile) handleBufferedDirective(lineBufFunction *string, line string, lineBuf *[]string, state State, options ImportOptions, directive string, initFunc func(bool), importFunc func([]string) error, overwrite bool, stateField *bool, offsetField *uint8) error {
+	if err := apj.processData(lineBufFunction, lineBuf, state, options); err != nil {
+		return err
+	}
 	*lineBufFunction = directive
 	if !*stateField {
 		*stateField = true
 		initFunc(overwrite)
 		if offsetField != nil && !overwrite {
-			*offsetField = uint8(len(apj.Blocks))
+			// Record how many elements already exist so references can be remapped
+			switch directive {
+			case "DEFINESCREEN":
+				*offsetField = uint8(len(apj.Screens))
+			default:
+				*offsetField = uint8(len(apj.Blocks))
+			}
 		}
 	}
 	*lineBuf = append(*lineBuf, line)
+	return nil
 }
 
 // function to check if the line is been ignored or not
@@ -222,6 +249,8 @@ func (apj *APJFile) checkIgnore(lineBufFunction string, options ImportOptions) b
 		return true
 	} else if strings.HasPrefix(lineBufFunction, "DEFINEPALETTE") && options.ignoreULAPalette {
 		return true
+	} else if strings.HasPrefix(lineBufFunction, "DEFINEMESSAGES") && options.ignoreMessages {
+		return true
 	} else if lineBufFunction == "" {
 		return true
 	}
@@ -262,7 +291,14 @@ func (apj *APJFile) processData(lineBufFunction *string, lineBuf *[]string, stat
 		*lineBufFunction = ""
 		*lineBuf = make([]string, 0)
 	case "MAP":
-		//apj.ImportMap(*lineBuf)
+		// Point the map at the appended screens
+		screenOffSet := uint8(0)
+		if !options.owScreens {
+			screenOffSet = state.ScreensOffSet
+		}
+		if err := apj.importMap(*lineBuf, screenOffSet); err != nil {
+			return fmt.Errorf("error importing map: %w", err)
+		}
 		*lineBufFunction = ""
 		*lineBuf = make([]string, 0)
 	case "DEFINEFONT":
@@ -279,6 +315,13 @@ func (apj *APJFile) processData(lineBufFunction *string, lineBuf *[]string, stat
 		}
 		*lineBufFunction = ""
 		*lineBuf = make([]string, 0)
+	case "DEFINEMESSAGES":
+		err := apj.ImportMessages(*lineBuf)
+		if err != nil {
+			return fmt.Errorf("error importing messages: %w", err)
+		}
+		*lineBufFunction = ""
+		*lineBuf = make([]string, 0)
 	}
 
 	return nil
@@ -4,9 +4,8 @@ import (
 	"encoding/binary"
 	"fmt"
 	"image"
-	"image/png"
+	"image/color"
 	"io"
-	"os"
 	"sort"
 	"strings"
 )
@@ -88,6 +87,82 @@ func (apj *APJFile) ImportScreens(lines []string) error {
 	return nil
 }
 
+// ImportScreenFromPNG slices a Windows.Width*8 x Windows.Height*8 PNG image into 8x8 cells and
+// appends a screen built from them. Cells with the same bitmap and attribute as an existing block
+// reuse it, preferring a block of the given type, the other cells are added as new blocks of
+// that type. It returns the number of new blocks and the cells that use more colours than a
+// Spectrum attribute allows.
+func (apj *APJFile) ImportScreenFromPNG(filePath string, blockType uint8) (int, []PNGCellIssue, error) {
+	img, err := ReadPNG(filePath)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to read PNG file: %w", err)
+	}
+
+	size := 8
+	width, height := int(apj.Windows.Width), int(apj.Windows.Height)
+	bounds := img.Bounds()
+	if bounds.Dx() != width*size || bounds.Dy() != height*size {
+		return 0, nil, fmt.Errorf("image size %dx%d does not match the window size %dx%d", bounds.Dx(), bounds.Dy(), width*size, height*size)
+	}
+	if len(apj.Screens) >= 255 {
+		return 0, nil, fmt.Errorf("maximum number of screens reached")
+	}
+
+	// Index the existing blocks by their data, the first block of the given type wins over
+	// the first block of another type
+	apj.BlockInit(false)
+	known := make(map[string]uint8)
+	for i, block := range apj.Blocks {
+		signature := blockSignature(block.Spectrum)
+		if blockID, exists := known[signature]; !exists || (apj.Blocks[blockID].Type != blockType && block.Type == blockType) {
+			known[signature] = uint8(i)
+		}
+	}
+
+	var issues []PNGCellIssue
+	var newBlocks []Block
+	screenData := make([][]uint8, height)
+	for row := 0; row < height; row++ {
+		screenData[row] = make([]uint8, width)
+		for col := 0; col < width; col++ {
+			data, issue := cellToSpectrum(img, bounds.Min.X+col*size, bounds.Min.Y+row*size)
+			if issue != nil {
+				issue.Cell, issue.Column, issue.Row = row*width+col, col, row
+				issues = append(issues, *issue)
+			}
+
+			signature := blockSignature(data)
+			blockID, exists := known[signature]
+			if !exists {
+				if len(apj.Blocks)+len(newBlocks) >= 255 {
+					return 0, issues, fmt.Errorf("maximum number of blocks reached")
+				}
+				blockID = uint8(len(apj.Blocks) + len(newBlocks))
+				block := apj.createBlock(blockID, blockType)
+				block.Spectrum = data
+				newBlocks = append(newBlocks, block)
+				known[signature] = blockID
+			}
+			screenData[row][col] = blockID
+		}
+	}
+
+	// Only change the project once the whole image has been converted
+	if len(newBlocks) > 0 {
+		apj.Blocks = append(apj.Blocks, newBlocks...)
+		apj.NrOfBlocks = uint8(len(apj.Blocks))
+		apj.State.Blocks = true
+	}
+	apj.ScreensInit(false)
+	apj.Screens = append(apj.Screens, Screen{
+		ScreenID:   uint8(len(apj.Screens)),
+		ScreenData: screenData,
+	})
+	apj.NrOfScreens = uint8(len(apj.Screens))
+	apj.State.Screens = true
+	return len(newBlocks), issues, nil
+}
+
 // make a copy of the screen data then remap the blocks
 func (apj *APJFile) RemapScreens(screenIndex uint8, BlockOffSet uint8) {
 	screen := apj.Screens[screenIndex]
@@ -107,6 +182,34 @@ func (apj *APJFile) RemapScreens(screenIndex uint8, BlockOffSet uint8) {
 
 }
 
+// exportScreens writes a DEFINESCREEN directive for each screen, followed by its SPRITEPOSITION lines.
+func (apj *APJFile) exportScreens(f io.Writer) error {
+	for i, screen := range apj.Screens {
+		for y, row := range screen.ScreenData {
+			directive := ""
+			if y == 0 {
+				directive = "DEFINESCREEN"
+			}
+			if err := writeAGDLine(f, directive, formatValues(row, "%3d")); err != nil {
+				return err
+			}
+		}
+		for _, sprite := range apj.SpriteInfo {
+			if sprite.Screen != uint8(i) {
+				continue
+			}
+			data := fmt.Sprintf("%d %d %d %d", sprite.Type, sprite.Image, sprite.X, sprite.Y)
+			if err := writeAGDLine(f, "SPRITEPOSITION", data); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintln(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // readScreens reads screen data
 func (apj *APJFile) readScreens(f io.Reader) error {
 	var nrOfScreens uint8
@@ -150,19 +253,62 @@ func (apj *APJFile) writeScreens(f io.Writer) error {
 	return nil
 }
 
+// ScreenRenderOptions are the extras RenderScreenToBitmap draws on top of the blocks.
+type ScreenRenderOptions struct {
+	Sprites bool // Draw the first frame of each sprite placed on the screen, labelled with its sprite type
+	Objects bool // Draw the objects that start on the screen
+	Mono    bool // Draw ink in white and paper in black, for output without colours
+}
+
+// Colours used to draw the sprites and their labels on top of a screen.
+var (
+	screenSpriteColour = color.RGBA{255, 255, 255, 255}
+	screenLabelColour  = color.RGBA{255, 255, 0, 255}
+)
+
 // RenderScreenToBitmap renders the specified screen to a bitmap and saves it as a PNG file.
-func (apj *APJFile) RenderScreenToBitmap(screenIndex uint8, filePath string) error {
+func (apj *APJFile) RenderScreenToBitmap(screenIndex uint8, filePath string, options ScreenRenderOptions) error {
+	img, err := apj.RenderScreenImage(screenIndex, options)
+	if err != nil {
+		return err
+	}
+	return writePNG(filePath, img)
+}
+
+// RenderScreenToTerminal writes the specified screen to the terminal in its Spectrum colours,
+// or with the ink in the terminal's own colour when colour is false.
+func (apj *APJFile) RenderScreenToTerminal(w io.Writer, screenIndex uint8, options ScreenRenderOptions, colour bool) error {
+	options.Mono = options.Mono || !colour
+	img, err := apj.RenderScreenImage(screenIndex, options)
+	if err != nil {
+		return err
+	}
+	return WriteTerminalImage(w, img, colour)
+}
+
+// RenderScreenImage draws the screen and the extras in the options into a new image.
+func (apj *APJFile) RenderScreenImage(screenIndex uint8, options ScreenRenderOptions) (*image.RGBA, error) {
 	if int(screenIndex) >= len(apj.Screens) {
-		return fmt.Errorf("screen index out of range")
+		return nil, fmt.Errorf("screen index out of range")
 	}
 
-	screen := apj.Screens[screenIndex]
 	img := image.NewRGBA(image.Rect(0, 0, int(apj.Windows.Width*8), int(apj.Windows.Height*8)))
+	if err := apj.drawScreen(img, screenIndex, 0, 0, options.Mono); err != nil {
+		return nil, err
+	}
+	apj.drawScreenOverlays(img, screenIndex, 0, 0, options)
+	return img, nil
+}
 
+// drawScreen draws the blocks of a screen into the image with its top left corner at x0, y0.
+// With mono the ink is drawn in white and the paper in black instead of their colours.
+func (apj *APJFile) drawScreen(img *image.RGBA, screenIndex uint8, x0, y0 int, mono bool) error {
+	screen := apj.Screens[screenIndex]
 	for y, row := range screen.ScreenData {
 		for x, blockID := range row {
-			// Map blockID to a color (example: grayscale based on blockID)
-			// get the block
+			if int(blockID) >= len(apj.Blocks) {
+				return fmt.Errorf("screen %d uses block %d which does not exist", screenIndex, blockID)
+			}
 			data := apj.Blocks[blockID].Spectrum
 			sp, err := apj.BlockTo2DArray(data)
 			if err != nil {
@@ -172,45 +318,76 @@ func (apj *APJFile) RenderScreenToBitmap(screenIndex uint8, filePath string) err
 			attr := apj.Blocks[blockID].Spectrum[len(apj.Blocks[blockID].Spectrum)-1]
 			// get the color from the attribute byte
 			fg, bg := SpectrumAttrToColors(attr)
+			if mono {
+				fg, bg = color.White, color.Black
+			}
 
 			// draw the block in the image
 			for blockY := 0; blockY < int(8); blockY++ {
 				for blockX := 0; blockX < int(8); blockX++ {
-					//calc the pixle colour from spectrum attributew byte
-					// clear the upper bits
 					if sp[blockY][blockX] == 1 {
-						// Set the pixel color to white (or any other color you want)
-						img.Set(x*int(8)+blockX, y*int(8)+blockY, fg)
+						img.Set(x0+x*int(8)+blockX, y0+y*int(8)+blockY, fg)
 					} else {
-						// Set the pixel color to black (or any other color you want)
-						img.Set(x*int(8)+blockX, y*int(8)+blockY, bg)
-
+						img.Set(x0+x*int(8)+blockX, y0+y*int(8)+blockY, bg)
 					}
 				}
 			}
+		}
+	}
+	return nil
+}
 
-			// draw the block
-			// Here we just use a simple grayscale mapping for demonstration
-			// You can replace this with your own color mapping logic
-			// For example, you can use a color palette or a more complex mapping
+// drawScreenOverlays draws the objects and sprites of a screen drawn at x0, y0. Their positions are
+// screen coordinates, so the window position is taken off. SpriteInfo.X and the third object byte
+// are the vertical position, SpriteInfo.Y and the fourth object byte the horizontal one.
+func (apj *APJFile) drawScreenOverlays(img *image.RGBA, screenIndex uint8, x0, y0 int, options ScreenRenderOptions) {
+	clip := image.Rect(x0, y0, x0+int(apj.Windows.Width)*8, y0+int(apj.Windows.Height)*8)
+	left, top := x0-int(apj.Windows.Left)*8, y0-int(apj.Windows.Top)*8
 
-			//grayValue := uint8(blockID * 16) // Example mapping
-			//col := color.RGBA{grayValue, grayValue, grayValue, 255}
-			//img.Set(x, y, col)
+	if options.Objects {
+		for _, object := range apj.Objects {
+			if len(object.Spectrum) < 36 || object.Spectrum[1] != screenIndex {
+				continue
+			}
+			ink, _ := SpectrumAttrToColors(object.Spectrum[0])
+			if options.Mono {
+				ink = screenSpriteColour
+			}
+			apj.drawSpriteData(img, clip, left+int(object.Spectrum[3]), top+int(object.Spectrum[2]), object.Spectrum[4:36], ink)
 		}
 	}
 
-	file, err := os.Create(filePath)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
+	if options.Sprites {
+		for _, spritePos := range apj.SpriteInfo {
+			if spritePos.Screen != screenIndex || int(spritePos.Image) >= len(apj.Sprites) || len(apj.Sprites[spritePos.Image].Spectrum) == 0 {
+				continue
+			}
+			x, y := left+int(spritePos.Y), top+int(spritePos.X)
+			apj.drawSpriteData(img, clip, x, y, apj.Sprites[spritePos.Image].Spectrum[0].ImageData, screenSpriteColour)
 
-	if err := png.Encode(file, img); err != nil {
-		return err
+			// Label the sprite type above the sprite, or below it at the top of the screen
+			labelY := y - 8
+			if labelY < clip.Min.Y {
+				labelY = y + 16
+			}
+			apj.drawText(img, x, labelY, fmt.Sprintf("%d", spritePos.Type), screenLabelColour, color.Black)
+		}
 	}
+}
 
-	return nil
+// drawSpriteData draws the set pixels of 16x16 sprite data in the colour, the clear pixels are left as they are.
+func (apj *APJFile) drawSpriteData(img *image.RGBA, clip image.Rectangle, x0, y0 int, data []uint8, ink color.Color) {
+	if len(data) < 32 {
+		return
+	}
+	pixels, _ := apj.SpriteTo2DArray(data)
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 16; x++ {
+			if pixels[y][x] == 1 && image.Pt(x0+x, y0+y).In(clip) {
+				img.Set(x0+x, y0+y, ink)
+			}
+		}
+	}
 }
 
 // ReorderScreens reorders the screens based on the provided order slice.
@@ -259,3 +436,276 @@ func (apj *APJFile) ReorderScreens(order []int) error {
 	apj.Screens = newScreens
 	return nil
 }
+
+// newScreenData returns the cells of a screen the size of the window, filled with the given block.
+func (apj *APJFile) newScreenData(blockID uint8) [][]uint8 {
+	screenData := make([][]uint8, apj.Windows.Height)
+	for y := range screenData {
+		screenData[y] = make([]uint8, apj.Windows.Width)
+		for x := range screenData[y] {
+			screenData[y][x] = blockID
+		}
+	}
+	return screenData
+}
+
+// remapScreenReferences changes the screen references in the map, the sprite positions and the
+// object rooms from their old screen to mapping[old screen]. A mapping of -1 means the screen was
+// deleted, its map cells become empty (255), its sprite positions are dropped and its objects
+// are moved to ObjectNoRoom. It returns the number of sprite positions dropped and objects moved.
+func (apj *APJFile) remapScreenReferences(mapping []int) (int, int) {
+	for y := range apj.Map.Map {
+		for x, screenID := range apj.Map.Map[y] {
+			if int(screenID) < len(mapping) {
+				if mapping[screenID] < 0 {
+					apj.Map.Map[y][x] = 255
+				} else {
+					apj.Map.Map[y][x] = uint8(mapping[screenID])
+				}
+			}
+		}
+	}
+	if int(apj.Map.StartScreen) < len(mapping) && mapping[apj.Map.StartScreen] >= 0 {
+		apj.Map.StartScreen = uint8(mapping[apj.Map.StartScreen])
+	}
+
+	removed := 0
+	spriteInfo := make([]SpriteInfo, 0, len(apj.SpriteInfo))
+	for _, spritePos := range apj.SpriteInfo {
+		if int(spritePos.Screen) < len(mapping) {
+			if mapping[spritePos.Screen] < 0 {
+				removed++
+				continue
+			}
+			spritePos.Screen = uint8(mapping[spritePos.Screen])
+		}
+		spriteInfo = append(spriteInfo, spritePos)
+	}
+	// Keep the sprite positions grouped by screen, as they are written to the file
+	sort.SliceStable(spriteInfo, func(i, j int) bool {
+		return spriteInfo[i].Screen < spriteInfo[j].Screen
+	})
+	apj.SpriteInfo = spriteInfo
+
+	// The room of an object is the second byte of its Spectrum data
+	moved := 0
+	for i := range apj.Objects {
+		if len(apj.Objects[i].Spectrum) < 2 {
+			continue
+		}
+		room := apj.Objects[i].Spectrum[1]
+		if int(room) < len(mapping) {
+			if mapping[room] < 0 {
+				apj.Objects[i].Spectrum[1] = ObjectNoRoom
+				moved++
+			} else {
+				apj.Objects[i].Spectrum[1] = uint8(mapping[room])
+			}
+		}
+	}
+	return removed, moved
+}
+
+// setScreens replaces the screens and renumbers them.
+func (apj *APJFile) setScreens(screens []Screen) {
+	for i := range screens {
+		screens[i].ScreenID = uint8(i)
+	}
+	apj.Screens = screens
+	apj.NrOfScreens = uint8(len(screens))
+}
+
+// InsertScreen inserts a screen filled with the given block at the index, and moves
+// the map cells, sprite positions and objects of the screens after it up by one.
+func (apj *APJFile) InsertScreen(index int, blockID uint8) error {
+	if int(blockID) >= len(apj.Blocks) {
+		return fmt.Errorf("block %d does not exist", blockID)
+	}
+	return apj.insertScreen(index, Screen{ScreenData: apj.newScreenData(blockID)})
+}
+
+// insertScreen inserts the screen at the index and updates the references to the screens after it.
+func (apj *APJFile) insertScreen(index int, screen Screen) error {
+	if index < 0 || index > len(apj.Screens) {
+		return fmt.Errorf("screen index %d is out of range, the project has %d screens", index, len(apj.Screens))
+	}
+	if len(apj.Screens) >= 255 {
+		return fmt.Errorf("the project already has the maximum of 255 screens")
+	}
+
+	mapping := make([]int, len(apj.Screens))
+	for i := range mapping {
+		mapping[i] = i
+		if i >= index {
+			mapping[i] = i + 1
+		}
+	}
+	apj.remapScreenReferences(mapping)
+	apj.setScreens(append(apj.Screens[:index], append([]Screen{screen}, apj.Screens[index:]...)...))
+	return nil
+}
+
+// DuplicateScreen inserts a copy of a screen at the index, or at the end when the index is -1.
+// When copySprites is true the sprite positions of the screen are copied as well.
+// It returns the index of the new screen.
+func (apj *APJFile) DuplicateScreen(screenIndex, index int, copySprites bool) (int, error) {
+	if screenIndex < 0 || screenIndex >= len(apj.Screens) {
+		return 0, fmt.Errorf("screen %d does not exist", screenIndex)
+	}
+	if index == -1 {
+		index = len(apj.Screens)
+	}
+	screenData := make([][]uint8, len(apj.Screens[screenIndex].ScreenData))
+	for y, row := range apj.Screens[screenIndex].ScreenData {
+		screenData[y] = append([]uint8(nil), row...)
+	}
+	if err := apj.insertScreen(index, Screen{ScreenData: screenData}); err != nil {
+		return 0, err
+	}
+	if screenIndex >= index {
+		screenIndex++ // The source moved up when the copy was inserted before it
+	}
+
+	if copySprites {
+		for _, spritePos := range apj.SpriteInfo {
+			if int(spritePos.Screen) == screenIndex {
+				spritePos.Screen = uint8(index)
+				apj.SpriteInfo = append(apj.SpriteInfo, spritePos)
+			}
+		}
+		sort.SliceStable(apj.SpriteInfo, func(i, j int) bool {
+			return apj.SpriteInfo[i].Screen < apj.SpriteInfo[j].Screen
+		})
+	}
+	return index, nil
+}
+
+// DeleteScreens removes the given screens. Their map cells become empty (255), their sprite
+// positions are dropped, their objects are moved to ObjectNoRoom and the screens after them
+// move down. The start screen can not be deleted.
+// It returns the number of sprite positions dropped and the number of objects moved.
+func (apj *APJFile) DeleteScreens(ids []int) (int, int, error) {
+	deleted := make(map[int]bool)
+	for _, id := range ids {
+		if id < 0 || id >= len(apj.Screens) {
+			return 0, 0, fmt.Errorf("screen %d does not exist", id)
+		}
+		if id == int(apj.Map.StartScreen) {
+			return 0, 0, fmt.Errorf("screen %d is the start screen", id)
+		}
+		deleted[id] = true
+	}
+
+	mapping := make([]int, len(apj.Screens))
+	screens := make([]Screen, 0, len(apj.Screens)-len(deleted))
+	for i, screen := range apj.Screens {
+		if deleted[i] {
+			mapping[i] = -1
+			continue
+		}
+		mapping[i] = len(screens)
+		screens = append(screens, screen)
+	}
+
+	removed, moved := apj.remapScreenReferences(mapping)
+	apj.setScreens(screens)
+	return removed, moved, nil
+}
+
+// BlockLocation is a screen cell using a block.
+type BlockLocation struct {
+	Screen int   // Screen index
+	X      int   // Column of the cell
+	Y      int   // Row of the cell
+	Block  uint8 // Block used by the cell
+}
+
+// ScreenRegion is a rectangle of screen cells.
+type ScreenRegion struct {
+	X, Y          int // Column and row of the top left cell
+	Width, Height int // Size of the region in cells
+}
+
+// ParseScreenRegion parses a region in the form "x,y,width,height".
+func ParseScreenRegion(spec string) (*ScreenRegion, error) {
+	values, err := ParseIntList(spec)
+	if err != nil || len(values) != 4 || values[2] < 1 || values[3] < 1 {
+		return nil, fmt.Errorf("invalid region %q, expected x,y,width,height", spec)
+	}
+	return &ScreenRegion{X: values[0], Y: values[1], Width: values[2], Height: values[3]}, nil
+}
+
+// contains returns true when the cell is inside the region, a nil region contains every cell.
+func (region *ScreenRegion) contains(x, y int) bool {
+	return region == nil || (x >= region.X && x < region.X+region.Width && y >= region.Y && y < region.Y+region.Height)
+}
+
+// screenIndexes returns the given screens after checking they exist, or every screen when none are given.
+func (apj *APJFile) screenIndexes(screens []int) ([]int, error) {
+	if len(screens) == 0 {
+		screens = make([]int, len(apj.Screens))
+		for i := range screens {
+			screens[i] = i
+		}
+	}
+	for _, screen := range screens {
+		if screen < 0 || screen >= len(apj.Screens) {
+			return nil, fmt.Errorf("screen %d does not exist", screen)
+		}
+	}
+	return screens, nil
+}
+
+// BlocksOfType returns the IDs of the blocks with the given type.
+func (apj *APJFile) BlocksOfType(blockType uint8) []int {
+	ids := make([]int, 0)
+	for i, block := range apj.Blocks {
+		if block.Type == blockType {
+			ids = append(ids, i)
+		}
+	}
+	return ids
+}
+
+// FindBlocks returns every cell of the given screens using one of the blocks, or every screen when none are given.
+func (apj *APJFile) FindBlocks(blocks []int, screens []int) ([]BlockLocation, error) {
+	screens, err := apj.screenIndexes(screens)
+	if err != nil {
+		return nil, err
+	}
+	locations := make([]BlockLocation, 0)
+	for _, screen := range screens {
+		for y, row := range apj.Screens[screen].ScreenData {
+			for x, blockID := range row {
+				for _, block := range blocks {
+					if int(blockID) == block {
+						locations = append(locations, BlockLocation{Screen: screen, X: x, Y: y, Block: blockID})
+						break
+					}
+				}
+			}
+		}
+	}
+	return locations, nil
+}
+
+// ReplaceBlock changes the cells using the from block to the to block, in the given screens or every
+// screen when none are given, and only inside the region when it is not nil.
+// It returns the number of cells changed.
+func (apj *APJFile) ReplaceBlock(from, to uint8, screens []int, region *ScreenRegion) (int, error) {
+	if int(to) >= len(apj.Blocks) {
+		return 0, fmt.Errorf("block %d does not exist", to)
+	}
+	locations, err := apj.FindBlocks([]int{int(from)}, screens)
+	if err != nil {
+		return 0, err
+	}
+	replaced := 0
+	for _, location := range locations {
+		if region.contains(location.X, location.Y) {
+			apj.Screens[location.Screen].ScreenData[location.Y][location.X] = to
+			replaced++
+		}
+	}
+	return replaced, nil
+}
@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"image"
 	"image/color"
-	"image/png"
+	"image/draw"
+	"image/gif"
 	"io"
+	"math/bits"
 	"os"
 	"regexp"
 	"strings"
@@ -211,6 +213,103 @@ func (apj *APJFile) ImportSprites(lines []string) error {
 	return nil
 }
 
+// ImportSpritesFromPNG reads 16x16 cells from a PNG sprite sheet, or from each PNG file in a
+// directory, and groups every frames consecutive cells into the frames of one sprite.
+// When frames is 0 all the cells of a file become the frames of one sprite.
+// The sprites are appended, or replace the existing sprites when replace is true.
+// gap is the number of pixels between the cells. It returns the number of sprites imported.
+func (apj *APJFile) ImportSpritesFromPNG(path string, frames int, replace bool, gap int) (int, error) {
+	if frames < 0 || frames > 255 {
+		return 0, fmt.Errorf("invalid number of frames: %d", frames)
+	}
+	files, err := ListPNGFiles(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find PNG files: %w", err)
+	}
+
+	// Read the cells of each file, a sprite never spans two files when frames is 0
+	size := 16
+	var groups [][][]uint8
+	var cells [][]uint8
+	for _, file := range files {
+		img, err := ReadPNG(file)
+		if err != nil {
+			return 0, fmt.Errorf("failed to read PNG file: %w", err)
+		}
+		columns, rows := CountPNGCells(img, size, gap)
+		bounds := img.Bounds()
+		for row := 0; row < rows; row++ {
+			for col := 0; col < columns; col++ {
+				cells = append(cells, cellToBitmap(img, bounds.Min.X+col*(size+gap), bounds.Min.Y+row*(size+gap), size))
+			}
+		}
+		if frames == 0 && len(cells) > 0 {
+			groups = append(groups, cells)
+			cells = nil
+		}
+	}
+	if frames > 0 {
+		for len(cells) >= frames {
+			groups = append(groups, cells[:frames])
+			cells = cells[frames:]
+		}
+		if len(cells) > 0 {
+			LogMessage("ImportSpritesFromPNG", fmt.Sprintf("%d cells left over after the last sprite were skipped", len(cells)), "warning", apj.noColor)
+		}
+	}
+	if len(groups) == 0 {
+		return 0, fmt.Errorf("no sprite cells found in %s", path)
+	}
+
+	apj.SpriteInit(replace)
+	totalFrames := len(groups)
+	for _, sprite := range apj.Sprites {
+		totalFrames += int(sprite.Frames) - 1
+	}
+	for _, group := range groups {
+		totalFrames += len(group) - 1
+	}
+	if len(apj.Sprites)+len(groups) > 255 || totalFrames > 255 {
+		return 0, fmt.Errorf("too many sprites: %d sprites with %d frames", len(apj.Sprites)+len(groups), totalFrames)
+	}
+
+	for _, group := range groups {
+		sprite := apj.createSprite(uint8(len(apj.Sprites)), 0, uint8(len(group)))
+		apj.SetFrameDefaults(&sprite)
+		for frame, data := range group {
+			sprite.Spectrum[frame].ImageData = data
+		}
+		apj.Sprites = append(apj.Sprites, sprite)
+	}
+
+	apj.NrOfSprites = uint8(len(apj.Sprites))
+	apj.CalcOffset()
+	apj.State.Sprites = true
+	return len(groups), nil
+}
+
+// exportSprites writes a DEFINESPRITE directive for each sprite to the given writer.
+// Each frame is written as two lines of 16 values.
+func (apj *APJFile) exportSprites(f io.Writer) error {
+	for _, sprite := range apj.Sprites {
+		if err := writeAGDLine(f, "DEFINESPRITE", fmt.Sprintf("%d", sprite.Frames)); err != nil {
+			return err
+		}
+		for _, frame := range sprite.Spectrum {
+			for i := 0; i < len(frame.ImageData); i += 16 {
+				end := min(i+16, len(frame.ImageData))
+				if err := writeAGDLine(f, "", formatValues(frame.ImageData[i:end], "%d")); err != nil {
+					return err
+				}
+			}
+		}
+		if _, err := fmt.Fprintln(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // CalcImageSize calculates the image size based on the number of sprites and layout
 func (apj *APJFile) CalcOffset() {
 	offset := uint8(0)
@@ -326,44 +425,65 @@ func (apj *APJFile) writeSprite(f io.Writer) error {
 }
 
 // RotateSprite rotates a sprite by 90 degrees clockwise or counter-clockwise
+// When retain is true the rotated sprite is added to the end of the sprites and the original is kept.
 func (apj *APJFile) RotateSprite(spriteIndex uint8, ccw bool, retain bool) (uint8, error) {
 	// Check if the sprite index is valid
 	if spriteIndex >= uint8(len(apj.Sprites)) {
 		return spriteIndex, fmt.Errorf("block index out of range: %d", spriteIndex)
 	}
 	// Create a new sprite if retain is true
-	// Otherwise, use the existing sprite
-	var sprite Sprite
+	// Otherwise, rotate the existing sprite
+	sprite := &apj.Sprites[spriteIndex]
 	if retain {
-		sprite = apj.createSprite(uint8(len(apj.Sprites)), apj.Sprites[spriteIndex].OffSet, apj.Sprites[spriteIndex].Frames)
-	} else {
-		sprite = apj.Sprites[spriteIndex]
+		copied := apj.Sprites[spriteIndex].clone()
+		copied.SpriteID = uint8(len(apj.Sprites))
+		sprite = &copied
 	}
 
-	// Rotate the sprite's Spectrum data 90 degrees clockwise
-	for i := 0; i < len(apj.Sprites[spriteIndex].Spectrum); i++ {
-		if retain && len(sprite.Spectrum) < len(apj.Sprites[spriteIndex].Spectrum) {
-			sprite.Spectrum = append(sprite.Spectrum, SpriteFrame{
-				Frame:     i,
-				ImageData: make([]uint8, 32),
-			})
-			apj.SetFrameDefaults(&sprite)
-		}
-		sprite.Spectrum[i].ImageData = spriteRotate90(apj.Sprites[spriteIndex].Spectrum[i].ImageData, ccw)
+	// Rotate every frame of the platforms that use the Spectrum bitmap layout
+	for i := 0; i < int(sprite.Frames); i++ {
+		sprite.rotateFrame(i, ccw)
 	}
 
 	if retain {
-		apj.Sprites = append(apj.Sprites, sprite)
+		apj.Sprites = append(apj.Sprites, *sprite)
 		apj.NrOfSprites++
+		apj.CalcOffset()
 	}
-	//apj.Blocks[spriteIndex].Timex = rotate90CW(apj.Blocks[spriteIndex].Timex, 8, 8)
-	//apj.Blocks[spriteIndex].CPC = rotate90CW(apj.Blocks[spriteIndex].CPC, 8, 8)
-	//apj.Blocks[spriteIndex].Atom = rotate90CW(apj.Blocks[spriteIndex].Atom, 8, 8)
-	//apj.Blocks[spriteIndex].MSX = rotate90CW(apj.Blocks[spriteIndex].MSX, 8, 8)
-	//apj.Blocks[spriteIndex].AtomColour = rotate90CW(apj.Blocks[spriteIndex].AtomColour, 8, 8)
 	return uint8(len(apj.Sprites) - 1), nil
 }
 
+// RotateSpriteToFrames adds rotations of a sprite as extra frames of the same sprite.
+// Each rotation adds a rotated copy of the sprite's original frames, rotated from the frames before them.
+func (apj *APJFile) RotateSpriteToFrames(spriteIndex uint8, ccw bool, rotations int) error {
+	if int(spriteIndex) >= len(apj.Sprites) {
+		return fmt.Errorf("sprite index out of range: %d", spriteIndex)
+	}
+	sprite := &apj.Sprites[spriteIndex]
+	frames := int(sprite.Frames)
+	totalFrames := 0
+	for _, s := range apj.Sprites {
+		totalFrames += int(s.Frames)
+	}
+	if totalFrames+frames*rotations > 255 {
+		return fmt.Errorf("adding %d frames would give %d frames, the maximum is 255", frames*rotations, totalFrames+frames*rotations)
+	}
+
+	sprite.padFrames()
+	for r := 1; r <= rotations; r++ {
+		for f := 0; f < frames; f++ {
+			source := (r-1)*frames + f
+			for _, platform := range sprite.platformFrames() {
+				*platform = append(*platform, SpriteFrame{ImageData: append([]uint8(nil), (*platform)[source].ImageData...)})
+			}
+			sprite.rotateFrame(len(sprite.Spectrum)-1, ccw)
+		}
+	}
+	sprite.renumberFrames()
+	apj.CalcOffset()
+	return nil
+}
+
 // RotateSprite rotates a sprite by 90 degrees clockwise or counter-clockwise
 func spriteRotate90(data []uint8, ccw bool) []uint8 {
 	rotated := make([]uint8, len(data))
@@ -393,7 +513,7 @@ func spriteRotate90(data []uint8, ccw bool) []uint8 {
 		// then rotate it 90 degrees counter-clockwis
 		for y := 0; y < 16; y++ {
 			for x := 0; x < 16; x++ {
-				rotatedData[7-x][y] = blockData[y][x]
+				rotatedData[15-x][y] = blockData[y][x]
 
 			}
 		}
@@ -401,13 +521,14 @@ func spriteRotate90(data []uint8, ccw bool) []uint8 {
 		// then rotate it 90 degrees clockwis
 		for y := 0; y < 16; y++ {
 			for x := 0; x < 16; x++ {
-				rotatedData[x][y] = blockData[y][15-x]
+				rotatedData[x][15-y] = blockData[y][x]
 
 			}
 		}
 	}
 	// convert the 2d array back into a 1d array
 	dataCursor = 0
+	subId = 0
 	for i := 0; i < 16; i++ {
 		for j := 0; j < 16; j++ {
 			if j == 8 {
@@ -427,6 +548,44 @@ func spriteRotate90(data []uint8, ccw bool) []uint8 {
 	return rotated
 }
 
+// FlipSprite mirrors every frame of a sprite horizontally, or vertically when vertical is true.
+func (apj *APJFile) FlipSprite(spriteIndex uint8, vertical bool, retain bool) (uint8, error) {
+	if spriteIndex >= uint8(len(apj.Sprites)) {
+		return spriteIndex, fmt.Errorf("sprite index out of range: %d", spriteIndex)
+	}
+	sprite := &apj.Sprites[spriteIndex]
+	if retain {
+		copied := apj.Sprites[spriteIndex].clone()
+		copied.SpriteID = uint8(len(apj.Sprites))
+		sprite = &copied
+	}
+
+	for i := 0; i < int(sprite.Frames); i++ {
+		sprite.flipFrame(i, vertical)
+	}
+
+	if retain {
+		apj.Sprites = append(apj.Sprites, *sprite)
+		apj.NrOfSprites++
+		apj.CalcOffset()
+	}
+	return uint8(len(apj.Sprites) - 1), nil
+}
+
+// spriteFlip mirrors 16x16 sprite data, stored as two bytes per row.
+func spriteFlip(data []uint8, vertical bool) []uint8 {
+	flipped := make([]uint8, len(data))
+	for row := 0; row < 16; row++ {
+		if vertical {
+			copy(flipped[row*2:row*2+2], data[(15-row)*2:(15-row)*2+2])
+		} else {
+			flipped[row*2] = bits.Reverse8(data[row*2+1])
+			flipped[row*2+1] = bits.Reverse8(data[row*2])
+		}
+	}
+	return flipped
+}
+
 // SpriteTo2DArray converts a sprite's image data into a 2D array representation
 // It assumes the sprite is 16x16 pixels and each pixel is represented by a bit in the byte array.
 func (apj *APJFile) SpriteTo2DArray(data []uint8) ([][]uint8, error) {
@@ -500,6 +659,9 @@ func (apj *APJFile) GetReorderedSprites(order []int, offset int) ([]Sprite, erro
 
 	return newSprites, nil
 }
+
+// RenderSpriteToSeperateBitmap renders each sprite in the range to its own file in ImagePath.
+// The frames of a sprite are written side by side, so the files can be imported again with import-png.
 func (apj *APJFile) RenderSpriteToSeperateBitmap(startIndex, endIndex uint8, ImagePath string, reorder []int, offset int) error {
 	// Validate sprite indexes
 	if startIndex >= uint8(len(apj.Sprites)) || endIndex > uint8(len(apj.Sprites)) || startIndex >= endIndex {
@@ -512,10 +674,28 @@ func (apj *APJFile) RenderSpriteToSeperateBitmap(startIndex, endIndex uint8, Ima
 		}
 	}
 
+	var err error
+	var data []Sprite
+	if len(reorder) > 0 {
+		data, err = apj.GetReorderedSprites(reorder, offset)
+		if err != nil {
+			return fmt.Errorf("failed to reorder sprites: %s", err)
+		}
+	} else {
+		data = apj.Sprites
+	}
+
 	// Create separate bitmap files for each sprite
+	size := 16
 	for i := startIndex; i < endIndex; i++ {
+		img := image.NewRGBA(image.Rect(0, 0, size*len(data[i].Spectrum), size))
+		for frame, frameData := range data[i].Spectrum {
+			if err := apj.drawSpriteFrame(img, frameData.ImageData, frame*size, 0); err != nil {
+				return fmt.Errorf("failed to render sprite %d to bitmap: %s", i, err)
+			}
+		}
 		spriteFilePath := fmt.Sprintf("%s/sprite_%d.png", ImagePath, i)
-		if err := apj.RenderSpriteToBitmap(i, i+1, spriteFilePath, reorder, offset); err != nil {
+		if err := writePNG(spriteFilePath, img); err != nil {
 			return fmt.Errorf("failed to render sprite %d to bitmap: %s", i, err)
 		}
 	}
@@ -523,76 +703,177 @@ func (apj *APJFile) RenderSpriteToSeperateBitmap(startIndex, endIndex uint8, Ima
 	return nil
 }
 
-// RenderSpriteToBitmap renders a range of sprites to a bitmap file
-func (apj *APJFile) RenderSpriteToBitmap(startIndex, endIndex uint8, filePath string, reorder []int, offset int) error {
-	// Validate sprite indexes
-	if startIndex >= uint8(len(apj.Sprites)) || endIndex > uint8(len(apj.Sprites)) || startIndex >= endIndex {
-		return fmt.Errorf("sprite index range out of bounds: %d-%d", startIndex, endIndex)
+// drawSpriteFrame draws the Spectrum data of a sprite frame into the image at the given position.
+func (apj *APJFile) drawSpriteFrame(img draw.Image, data []uint8, xOffset, yOffset int) error {
+	sp, err := apj.SpriteTo2DArray(data)
+	if err != nil {
+		return fmt.Errorf("failed to convert sprite to array: %s", err)
 	}
+	fg := color.RGBA{192, 192, 0, 255} // Yellow
+	bg := color.RGBA{0, 0, 0, 255}     // Black
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 16; x++ {
+			if sp[y][x] == 1 {
+				img.Set(xOffset+x, yOffset+y, fg)
+			} else {
+				img.Set(xOffset+x, yOffset+y, bg)
+			}
+		}
+	}
+	return nil
+}
 
-	// Calculate layout and image dimensions
-	size := 16
-	columns := int(8)
-	if endIndex-startIndex < uint8(columns) {
-		columns = int(endIndex - startIndex)
+// AllFrames renders every frame of a sprite side by side instead of a single frame.
+const AllFrames = -1
+
+// spriteFrameData returns the Spectrum data of a sprite frame, or nil when the sprite has no such frame.
+func spriteFrameData(sprite Sprite, frame int) []uint8 {
+	if frame < 0 || frame >= len(sprite.Spectrum) {
+		return nil
 	}
-	imageWidth, imageHeight := CalcImageSize(startIndex, endIndex, columns, size)
+	return sprite.Spectrum[frame].ImageData
+}
 
-	var err error
-	img := image.NewRGBA(image.Rect(0, 0, int(imageWidth), int(imageHeight)))
+// spriteFrameRange returns the frames to render for a sprite, all frames or just the given one.
+func spriteFrameRange(sprite Sprite, frame int) []int {
+	if frame != AllFrames {
+		return []int{frame}
+	}
+	frames := make([]int, len(sprite.Spectrum))
+	for i := range frames {
+		frames[i] = i
+	}
+	return frames
+}
 
+// RenderSpriteToBitmap renders a range of sprites to a bitmap file.
+// frame selects the frame to render, sprites without that frame are left empty.
+// With AllFrames each sprite is rendered on its own row with its frames side by side.
+func (apj *APJFile) RenderSpriteToBitmap(startIndex, endIndex uint8, filePath string, reorder []int, offset int, frame int) error {
+	img, err := apj.RenderSpriteImage(startIndex, endIndex, reorder, offset, frame)
+	if err != nil {
+		return err
+	}
+	return writePNG(filePath, img)
+}
+
+// RenderSpriteImage draws a range of sprites into an image, laid out as RenderSpriteToBitmap describes.
+func (apj *APJFile) RenderSpriteImage(startIndex, endIndex uint8, reorder []int, offset int, frame int) (*image.RGBA, error) {
+	// Validate sprite indexes
+	if startIndex >= uint8(len(apj.Sprites)) || endIndex > uint8(len(apj.Sprites)) || startIndex >= endIndex {
+		return nil, fmt.Errorf("sprite index range out of bounds: %d-%d", startIndex, endIndex)
+	}
+
+	var err error
 	var data []Sprite
 	if len(reorder) > 0 {
 		// Reorder the blocks based on the provided order
 		data, err = apj.GetReorderedSprites(reorder, offset)
 		if err != nil {
-			return fmt.Errorf("failed to reorder blocks: %s", err)
+			return nil, fmt.Errorf("failed to reorder blocks: %s", err)
 		}
 	} else {
 		data = apj.Sprites
 	}
+
+	// Calculate layout and image dimensions
+	size := 16
+	columns := int(8)
+	if endIndex-startIndex < uint8(columns) {
+		columns = int(endIndex - startIndex)
+	}
+	var img *image.RGBA
+	if frame == AllFrames {
+		maxFrames := 1
+		for spriteIndex := startIndex; spriteIndex < endIndex; spriteIndex++ {
+			maxFrames = max(maxFrames, len(data[spriteIndex].Spectrum))
+		}
+		rows := int(endIndex - startIndex)
+		img = image.NewRGBA(image.Rect(0, 0, maxFrames*(size+1)-1, rows*(size+1)-1))
+	} else {
+		imageWidth, imageHeight := CalcImageSize(startIndex, endIndex, columns, size)
+		img = image.NewRGBA(image.Rect(0, 0, int(imageWidth), int(imageHeight)))
+	}
+
 	// Render sprites into pixel data
 	for spriteIndex := startIndex; spriteIndex < endIndex; spriteIndex++ {
-		sp, err := apj.SpriteTo2DArray(data[spriteIndex].Spectrum[0].ImageData)
-		if err != nil {
-			return fmt.Errorf("failed to convert sprite to array: %s", err)
-		}
-		// Calculate sprite position in the image
-		xOffset, yOffset := CalcImageOffSet(spriteIndex, startIndex, columns, size)
-		fg := color.RGBA{192, 192, 0, 255} // Yellow
-		bg := color.RGBA{0, 0, 0, 255}     // Black
-		// Write sprite pixels into the pixel data
-		for y := 0; y < size; y++ {
-			for x := 0; x < size; x++ {
-				rowIndex := (yOffset + y) // BMP is bottom-up
-				colIndex := (xOffset + x)
-				if sp[y][x] == 1 {
-					// Set the pixel color to white (or any other color you want)
-					img.Set(colIndex, rowIndex, fg)
-				} else {
-					// Set the pixel color to black (or any other color you want)
-					img.Set(colIndex, rowIndex, bg)
-				}
+		for i, spriteFrame := range spriteFrameRange(data[spriteIndex], frame) {
+			frameData := spriteFrameData(data[spriteIndex], spriteFrame)
+			if frameData == nil {
+				continue // leave sprites without this frame empty
+			}
+			// Calculate sprite position in the image
+			xOffset, yOffset := CalcImageOffSet(spriteIndex, startIndex, columns, size)
+			if frame == AllFrames {
+				xOffset, yOffset = i*(size+1), int(spriteIndex-startIndex)*(size+1)
+			}
+			if err := apj.drawSpriteFrame(img, frameData, xOffset, yOffset); err != nil {
+				return nil, err
 			}
 		}
 	}
 
-	file, err := os.Create(filePath)
-	if err != nil {
-		return err
+	return img, nil
+}
+
+// RenderSpriteToGIF writes an animated GIF of each sprite in the range to ImagePath.
+// delay is the time each frame is shown in 100ths of a second.
+func (apj *APJFile) RenderSpriteToGIF(startIndex, endIndex uint8, ImagePath string, reorder []int, offset int, delay int) error {
+	// Validate sprite indexes
+	if startIndex >= uint8(len(apj.Sprites)) || endIndex > uint8(len(apj.Sprites)) || startIndex >= endIndex {
+		return fmt.Errorf("sprite index range out of bounds: %d-%d", startIndex, endIndex)
 	}
-	defer file.Close()
 
-	if err := png.Encode(file, img); err != nil {
-		return err
+	if _, err := os.Stat(ImagePath); os.IsNotExist(err) {
+		if err := os.MkdirAll(ImagePath, os.ModePerm); err != nil {
+			return fmt.Errorf("failed to create directory for sprite animations: %s", err)
+		}
 	}
 
+	var err error
+	var data []Sprite
+	if len(reorder) > 0 {
+		data, err = apj.GetReorderedSprites(reorder, offset)
+		if err != nil {
+			return fmt.Errorf("failed to reorder sprites: %s", err)
+		}
+	} else {
+		data = apj.Sprites
+	}
+
+	size := 16
+	palette := color.Palette{color.RGBA{0, 0, 0, 255}, color.RGBA{192, 192, 0, 255}}
+	for i := startIndex; i < endIndex; i++ {
+		anim := &gif.GIF{}
+		for _, frameData := range data[i].Spectrum {
+			img := image.NewPaletted(image.Rect(0, 0, size, size), palette)
+			if err := apj.drawSpriteFrame(img, frameData.ImageData, 0, 0); err != nil {
+				return fmt.Errorf("failed to render sprite %d to GIF: %s", i, err)
+			}
+			anim.Image = append(anim.Image, img)
+			anim.Delay = append(anim.Delay, delay)
+		}
+		if len(anim.Image) == 0 {
+			continue // nothing to animate
+		}
+
+		file, err := os.Create(fmt.Sprintf("%s/sprite_%d.gif", ImagePath, i))
+		if err != nil {
+			return err
+		}
+		err = gif.EncodeAll(file, anim)
+		file.Close()
+		if err != nil {
+			return fmt.Errorf("failed to write sprite %d GIF: %s", i, err)
+		}
+	}
 	return nil
 }
 
 // RenderSpriteToTerminal renders a range of sprites to the terminal using tcg
 // This function assumes that the terminal supports 2x3 pixel characters
-func (apj *APJFile) RenderSpriteToTerminal(startIndex, endIndex uint8, reorder []int, offset int) error {
+// frame selects the frame to render, with AllFrames each sprite is shown on its own row with its frames side by side.
+func (apj *APJFile) RenderSpriteToTerminal(startIndex, endIndex uint8, reorder []int, offset int, frame int) error {
 	if startIndex >= uint8(len(apj.Sprites)) || endIndex > uint8(len(apj.Sprites)) {
 		return fmt.Errorf("sprite index out of range: %d", startIndex)
 	}
@@ -616,26 +897,34 @@ func (apj *APJFile) RenderSpriteToTerminal(startIndex, endIndex uint8, reorder [
 		data = apj.Sprites
 	}
 	for spriteIndex := startIndex; spriteIndex < endIndex; spriteIndex++ {
-		sp, err := apj.SpriteTo2DArray(data[spriteIndex].Spectrum[0].ImageData)
-		if err != nil {
-			return fmt.Errorf("failed to convert sprite to array: %s", err)
-		}
-		// Calculate sprite position in the image
-		xOffset, yOffset := CalcImageOffSet(spriteIndex, startIndex, columns, size)
+		for i, spriteFrame := range spriteFrameRange(data[spriteIndex], frame) {
+			frameData := spriteFrameData(data[spriteIndex], spriteFrame)
+			if frameData == nil {
+				continue // leave sprites without this frame empty
+			}
+			sp, err := apj.SpriteTo2DArray(frameData)
+			if err != nil {
+				return fmt.Errorf("failed to convert sprite to array: %s", err)
+			}
+			// Calculate sprite position in the image
+			xOffset, yOffset := CalcImageOffSet(spriteIndex, startIndex, columns, size)
+			if frame == AllFrames {
+				xOffset, yOffset = i*(size+1), int(spriteIndex-startIndex)*(size+1)
+			}
 
-		for y := 0; y < 16; y++ {
-			for x := 0; x < 16; x++ {
-				pixel := sp[y][x]
-				rowIndex := (yOffset + y) // BMP is bottom-up
-				colIndex := (xOffset + x)
-				if pixel == 1 {
-					tg.Buf.Set(colIndex, rowIndex, 1) // draw one pixel with color from 10,10
-				} else {
-					tg.Buf.Set(colIndex, rowIndex, 0) // draw one pixel with color from 10,10
+			for y := 0; y < 16; y++ {
+				for x := 0; x < 16; x++ {
+					pixel := sp[y][x]
+					rowIndex := (yOffset + y) // BMP is bottom-up
+					colIndex := (xOffset + x)
+					if pixel == 1 {
+						tg.Buf.Set(colIndex, rowIndex, 1) // draw one pixel with color from 10,10
+					} else {
+						tg.Buf.Set(colIndex, rowIndex, 0) // draw one pixel with color from 10,10
+					}
 				}
 			}
 		}
-
 	}
 
 	tg.Show() // synchronize buffer with screen
@@ -669,3 +958,202 @@ func (apj *APJFile) ReorderSprites(order []int, offset int) error {
 	apj.CalcOffset() // Recalculate offsets after reordering
 	return nil
 }
+
+// DeleteSpritesMapping returns the new ID of every sprite after the ids are deleted, -1 for a deleted sprite.
+func (apj *APJFile) DeleteSpritesMapping(ids []int) []int {
+	return deleteMapping(len(apj.Sprites), ids)
+}
+
+// DeleteSprites removes the given sprites and shifts the SpriteInfo images and the IMAGE and
+// SPAWN statements in the event code above them down. The code is written to the event files
+// of the project at codePath. A sprite that is still placed on a screen is not deleted unless
+// removePositions is true, in which case its sprite positions are removed too.
+// It returns the number of positions removed.
+func (apj *APJFile) DeleteSprites(ids []int, removePositions bool, codePath string) (int, error) {
+	mapping, removed, err := apj.deleteSprites(ids, removePositions)
+	if err != nil {
+		return 0, err
+	}
+	codeMappings := make(map[*regexp.Regexp][]int)
+	for _, pattern := range codeImagePatterns {
+		codeMappings[pattern] = mapping
+	}
+	return removed, apj.remapEventCode(codePath, codeMappings)
+}
+
+// deleteSprites removes the given sprites without changing the event code, it returns the new ID
+// of every old sprite and the number of positions removed.
+func (apj *APJFile) deleteSprites(ids []int, removePositions bool) ([]int, int, error) {
+	deleted := make(map[int]bool)
+	for _, id := range ids {
+		if id < 0 || id >= len(apj.Sprites) {
+			return nil, 0, fmt.Errorf("sprite %d does not exist", id)
+		}
+		deleted[id] = true
+	}
+	if len(deleted) >= len(apj.Sprites) {
+		return nil, 0, fmt.Errorf("can not delete all the sprites")
+	}
+
+	// Check the sprite positions before changing anything
+	if !removePositions {
+		for _, spritePos := range apj.SpriteInfo {
+			if deleted[int(spritePos.Image)] {
+				return nil, 0, fmt.Errorf("sprite %d is placed on screen %d", spritePos.Image, spritePos.Screen)
+			}
+		}
+	}
+
+	replacements := make(map[int]int)
+	for id := range deleted {
+		replacements[id] = -1
+	}
+	mapping, removed := apj.replaceSprites(replacements)
+	return mapping, removed, nil
+}
+
+// replaceSprites removes the sprites in replacements and changes the sprite positions using them
+// to the sprite they are replaced by, or removes the positions when it is replaced by -1.
+// It returns the new ID of every old sprite, -1 for a removed sprite, and the number of positions removed.
+func (apj *APJFile) replaceSprites(replacements map[int]int) ([]int, int) {
+	mapping := make([]int, len(apj.Sprites))
+	newSprites := make([]Sprite, 0, len(apj.Sprites)-len(replacements))
+	for i, sprite := range apj.Sprites {
+		if _, replaced := replacements[i]; replaced {
+			continue
+		}
+		mapping[i] = len(newSprites)
+		sprite.SpriteID = uint8(len(newSprites))
+		newSprites = append(newSprites, sprite)
+	}
+	for id, replacement := range replacements {
+		mapping[id] = -1
+		if replacement >= 0 {
+			mapping[id] = mapping[replacement]
+		}
+	}
+
+	removed := 0
+	spriteInfo := make([]SpriteInfo, 0, len(apj.SpriteInfo))
+	for _, spritePos := range apj.SpriteInfo {
+		if int(spritePos.Image) < len(mapping) {
+			if mapping[spritePos.Image] < 0 {
+				removed++
+				continue
+			}
+			spritePos.Image = uint8(mapping[spritePos.Image])
+		}
+		spriteInfo = append(spriteInfo, spritePos)
+	}
+
+	apj.SpriteInfo = spriteInfo
+	apj.Sprites = newSprites
+	apj.NrOfSprites = uint8(len(apj.Sprites))
+	apj.CalcOffset()
+	return mapping, removed
+}
+
+// InsertSpriteMapping returns the new ID of every sprite after a sprite is inserted at the index.
+func (apj *APJFile) InsertSpriteMapping(index int) []int {
+	mapping := make([]int, len(apj.Sprites))
+	for i := range mapping {
+		mapping[i] = i
+		if i >= index {
+			mapping[i]++
+		}
+	}
+	return mapping
+}
+
+// InsertSprite inserts a blank sprite with the given number of frames at the index, and shifts
+// the SpriteInfo images and the IMAGE and SPAWN statements in the event code at or above the
+// index up. The code is written to the event files of the project at codePath.
+func (apj *APJFile) InsertSprite(index int, frames int, codePath string) error {
+	if index < 0 || index > len(apj.Sprites) {
+		return fmt.Errorf("sprite index %d is out of range, the project has %d sprites", index, len(apj.Sprites))
+	}
+	if len(apj.Sprites) >= 255 {
+		return fmt.Errorf("the project already has the maximum of 255 sprites")
+	}
+	totalFrames := 0
+	for _, sprite := range apj.Sprites {
+		totalFrames += int(sprite.Frames)
+	}
+	if frames < 1 || totalFrames+frames > 255 {
+		return fmt.Errorf("can not add %d frames, the project has %d of the maximum 255 frames", frames, totalFrames)
+	}
+
+	mapping := apj.InsertSpriteMapping(index)
+	sprite := apj.createSprite(uint8(index), 0, uint8(frames))
+	apj.SetFrameDefaults(&sprite)
+	apj.Sprites = append(apj.Sprites[:index], append([]Sprite{sprite}, apj.Sprites[index:]...)...)
+	for i := range apj.Sprites {
+		apj.Sprites[i].SpriteID = uint8(i)
+	}
+
+	for i, spritePos := range apj.SpriteInfo {
+		if int(spritePos.Image) >= index {
+			apj.SpriteInfo[i].Image++
+		}
+	}
+
+	apj.NrOfSprites = uint8(len(apj.Sprites))
+	apj.CalcOffset()
+	codeMappings := make(map[*regexp.Regexp][]int)
+	for _, pattern := range codeImagePatterns {
+		codeMappings[pattern] = mapping
+	}
+	return apj.remapEventCode(codePath, codeMappings)
+}
+
+// FindDuplicateSprites returns the sprites with the same frames on every platform as an
+// earlier sprite. With spectrumOnly only the Spectrum frames are compared.
+func (apj *APJFile) FindDuplicateSprites(spectrumOnly bool) []Duplicate {
+	duplicates := make([]Duplicate, 0)
+	first := make(map[string]int)
+	for i, sprite := range apj.Sprites {
+		var key strings.Builder
+		fmt.Fprintf(&key, "%d", sprite.Frames)
+		platforms := [][]SpriteFrame{sprite.Spectrum}
+		if !spectrumOnly {
+			platforms = append(platforms, sprite.Timex, sprite.CPC, sprite.Atom, sprite.AtomColour, sprite.VZColour)
+		}
+		for _, frames := range platforms {
+			key.WriteString("/")
+			for _, frame := range frames {
+				fmt.Fprintf(&key, ":%x", frame.ImageData)
+			}
+		}
+		if id, exists := first[key.String()]; exists {
+			duplicates = append(duplicates, Duplicate{ID: i, Of: id})
+			continue
+		}
+		first[key.String()] = i
+	}
+	return duplicates
+}
+
+// MergeSprites removes the duplicate sprites and changes the sprite positions and the IMAGE and
+// SPAWN statements in the event code to use the sprite they duplicate. The code is written to
+// the event files of the project at codePath.
+func (apj *APJFile) MergeSprites(duplicates []Duplicate, codePath string) error {
+	replacements := make(map[int]int)
+	for _, duplicate := range duplicates {
+		if duplicate.ID < 0 || duplicate.ID >= len(apj.Sprites) || duplicate.Of < 0 || duplicate.Of >= len(apj.Sprites) {
+			return fmt.Errorf("sprite %d or %d does not exist", duplicate.ID, duplicate.Of)
+		}
+		replacements[duplicate.ID] = duplicate.Of
+	}
+	for _, replacement := range replacements {
+		if _, replaced := replacements[replacement]; replaced {
+			return fmt.Errorf("sprite %d is merged into a sprite that is also merged", replacement)
+		}
+	}
+
+	mapping, _ := apj.replaceSprites(replacements)
+	codeMappings := make(map[*regexp.Regexp][]int)
+	for _, pattern := range codeImagePatterns {
+		codeMappings[pattern] = mapping
+	}
+	return apj.remapEventCode(codePath, codeMappings)
+}
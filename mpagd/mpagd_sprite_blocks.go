@@ -0,0 +1,109 @@
+package mpagd
+
+import "fmt"
+
+// spriteQuarters are the x and y offsets of the four 8x8 quarters of a sprite frame,
+// in the order top left, top right, bottom left and bottom right.
+var spriteQuarters = [4][2]int{{0, 0}, {8, 0}, {0, 8}, {8, 8}}
+
+// spriteFrom2DArray converts a 16x16 grid of pixels into sprite data, the reverse of SpriteTo2DArray.
+func spriteFrom2DArray(grid [][]uint8) []uint8 {
+	data := make([]uint8, 32)
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 16; x++ {
+			if grid[y][x] == 1 {
+				data[y*2+x/8] |= 1 << (7 - x%8)
+			}
+		}
+	}
+	return data
+}
+
+// SpriteFrameFromBlocks builds the Spectrum data of a sprite frame from four blocks,
+// given in the order top left, top right, bottom left and bottom right.
+func (apj *APJFile) SpriteFrameFromBlocks(blocks [4]int) ([]uint8, error) {
+	grid := make([][]uint8, 16)
+	for i := range grid {
+		grid[i] = make([]uint8, 16)
+	}
+	for q, blockID := range blocks {
+		if blockID < 0 || blockID >= len(apj.Blocks) || len(apj.Blocks[blockID].Spectrum) < 8 {
+			return nil, fmt.Errorf("block %d does not exist", blockID)
+		}
+		pixels, err := apj.BlockTo2DArray(apj.Blocks[blockID].Spectrum)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read block %d: %w", blockID, err)
+		}
+		for y := 0; y < 8; y++ {
+			for x := 0; x < 8; x++ {
+				grid[spriteQuarters[q][1]+y][spriteQuarters[q][0]+x] = pixels[y][x]
+			}
+		}
+	}
+	return spriteFrom2DArray(grid), nil
+}
+
+// AddSpriteFromBlocks adds a sprite frame built from four blocks as a new sprite, or as the last
+// frame of the sprite when spriteIndex is not -1. It returns the sprite the frame was added to.
+func (apj *APJFile) AddSpriteFromBlocks(blocks [4]int, spriteIndex int) (int, error) {
+	data, err := apj.SpriteFrameFromBlocks(blocks)
+	if err != nil {
+		return 0, err
+	}
+	if spriteIndex == -1 {
+		if len(apj.Sprites) >= 255 {
+			return 0, fmt.Errorf("the project already has the maximum of 255 sprites")
+		}
+		if err := apj.checkFrameLimit(); err != nil {
+			return 0, err
+		}
+		sprite := apj.createSprite(uint8(len(apj.Sprites)), 0, 1)
+		apj.SetFrameDefaults(&sprite)
+		sprite.Spectrum[0].ImageData = data
+		apj.Sprites = append(apj.Sprites, sprite)
+		apj.NrOfSprites = uint8(len(apj.Sprites))
+		apj.CalcOffset()
+		return len(apj.Sprites) - 1, nil
+	}
+
+	if err := apj.AddSpriteFrame(spriteIndex, -1); err != nil {
+		return 0, err
+	}
+	sprite := &apj.Sprites[spriteIndex]
+	sprite.Spectrum[sprite.Frames-1].ImageData = data
+	return spriteIndex, nil
+}
+
+// BlocksFromSprite cuts a frame of a sprite into four new blocks of the block type with the
+// Spectrum attribute, added in the order top left, top right, bottom left and bottom right.
+// It returns the number of the first new block.
+func (apj *APJFile) BlocksFromSprite(spriteIndex, frame int, blockType, attr uint8) (int, error) {
+	if err := apj.checkSpriteFrame(spriteIndex, frame); err != nil {
+		return 0, err
+	}
+	if len(apj.Blocks)+4 > 255 {
+		return 0, fmt.Errorf("adding 4 blocks would give %d blocks, the maximum is 255", len(apj.Blocks)+4)
+	}
+	sprite := apj.Sprites[spriteIndex]
+	if frame >= len(sprite.Spectrum) || len(sprite.Spectrum[frame].ImageData) < 32 {
+		return 0, fmt.Errorf("sprite %d has no Spectrum data for frame %d", spriteIndex, frame)
+	}
+	pixels, err := apj.SpriteTo2DArray(sprite.Spectrum[frame].ImageData)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read sprite %d: %w", spriteIndex, err)
+	}
+
+	first := len(apj.Blocks)
+	for _, quarter := range spriteQuarters {
+		block := apj.createBlock(uint8(len(apj.Blocks)), blockType)
+		for y := 0; y < 8; y++ {
+			for x := 0; x < 8; x++ {
+				block.Spectrum[y] |= pixels[quarter[1]+y][quarter[0]+x] << (7 - x)
+			}
+		}
+		block.Spectrum[8] = attr
+		apj.Blocks = append(apj.Blocks, block)
+	}
+	apj.NrOfBlocks = uint8(len(apj.Blocks))
+	return first, nil
+}
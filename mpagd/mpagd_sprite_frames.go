@@ -0,0 +1,300 @@
+package mpagd
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// spriteFrameSizes are the sizes of a frame for each platform, in the order of platformFrames.
+var spriteFrameSizes = []int{32, 32, 80, 32, 32, 16}
+
+// platformFrames returns the frames of each platform of the sprite, so they can be changed together.
+func (sprite *Sprite) platformFrames() []*[]SpriteFrame {
+	return []*[]SpriteFrame{&sprite.Spectrum, &sprite.Timex, &sprite.CPC, &sprite.Atom, &sprite.AtomColour, &sprite.VZColour}
+}
+
+// monoPlatformFrames returns the frames of the platforms that use the Spectrum 16x16 bitmap layout.
+// The CPC and VZ colour frames use other layouts.
+func (sprite *Sprite) monoPlatformFrames() []*[]SpriteFrame {
+	return []*[]SpriteFrame{&sprite.Spectrum, &sprite.Timex, &sprite.Atom, &sprite.AtomColour}
+}
+
+// rotateFrame rotates a frame of the platforms that use the Spectrum bitmap layout by 90 degrees.
+func (sprite *Sprite) rotateFrame(frame int, ccw bool) {
+	for _, platform := range sprite.monoPlatformFrames() {
+		if frame < len(*platform) && len((*platform)[frame].ImageData) == 32 {
+			(*platform)[frame].ImageData = spriteRotate90((*platform)[frame].ImageData, ccw)
+		}
+	}
+}
+
+// flipFrame mirrors a frame of the platforms that use the Spectrum bitmap layout.
+func (sprite *Sprite) flipFrame(frame int, vertical bool) {
+	for _, platform := range sprite.monoPlatformFrames() {
+		if frame < len(*platform) && len((*platform)[frame].ImageData) == 32 {
+			(*platform)[frame].ImageData = spriteFlip((*platform)[frame].ImageData, vertical)
+		}
+	}
+}
+
+// clone returns a copy of the sprite that shares no frame data with it.
+func (sprite *Sprite) clone() Sprite {
+	copied := *sprite
+	copiedPlatforms := copied.platformFrames()
+	for i, platform := range sprite.platformFrames() {
+		*copiedPlatforms[i] = make([]SpriteFrame, len(*platform))
+		for j, frame := range *platform {
+			(*copiedPlatforms[i])[j] = SpriteFrame{Frame: frame.Frame, ImageData: append([]uint8(nil), frame.ImageData...)}
+		}
+	}
+	return copied
+}
+
+// editSpriteFrames applies the edit to the frames of every platform of a sprite, then renumbers
+// the frames and recalculates the sprite offsets. Platforms with missing frames are padded with
+// blank frames first, so every platform keeps the same number of frames.
+func (apj *APJFile) editSpriteFrames(spriteIndex int, edit func(frames []SpriteFrame, size int) []SpriteFrame) error {
+	if spriteIndex < 0 || spriteIndex >= len(apj.Sprites) {
+		return fmt.Errorf("sprite %d does not exist", spriteIndex)
+	}
+	sprite := &apj.Sprites[spriteIndex]
+	sprite.padFrames()
+	for i, platform := range sprite.platformFrames() {
+		*platform = edit(*platform, spriteFrameSizes[i])
+	}
+	sprite.renumberFrames()
+	apj.CalcOffset()
+	return nil
+}
+
+// padFrames makes every platform of the sprite have Frames frames, adding blank frames where needed.
+func (sprite *Sprite) padFrames() {
+	for i, platform := range sprite.platformFrames() {
+		for len(*platform) < int(sprite.Frames) {
+			*platform = append(*platform, SpriteFrame{ImageData: make([]uint8, spriteFrameSizes[i])})
+		}
+		*platform = (*platform)[:sprite.Frames]
+	}
+}
+
+// renumberFrames numbers the frames of every platform in order and updates the frame count.
+func (sprite *Sprite) renumberFrames() {
+	for _, platform := range sprite.platformFrames() {
+		for j := range *platform {
+			(*platform)[j].Frame = j
+		}
+	}
+	sprite.Frames = uint8(len(sprite.Spectrum))
+}
+
+// checkSpriteFrame returns an error when the frame is not a frame of the sprite.
+func (apj *APJFile) checkSpriteFrame(spriteIndex, frame int) error {
+	if spriteIndex < 0 || spriteIndex >= len(apj.Sprites) {
+		return fmt.Errorf("sprite %d does not exist", spriteIndex)
+	}
+	if frame < 0 || frame >= int(apj.Sprites[spriteIndex].Frames) {
+		return fmt.Errorf("sprite %d has no frame %d, it has %d frames", spriteIndex, frame, apj.Sprites[spriteIndex].Frames)
+	}
+	return nil
+}
+
+// checkFrameLimit returns an error when the project can not have another frame.
+func (apj *APJFile) checkFrameLimit() error {
+	totalFrames := 0
+	for _, sprite := range apj.Sprites {
+		totalFrames += int(sprite.Frames)
+	}
+	if totalFrames >= 255 {
+		return fmt.Errorf("the project already has the maximum of 255 frames")
+	}
+	return nil
+}
+
+// insertFrame returns the frames with the frame inserted at the position.
+func insertFrame(frames []SpriteFrame, position int, frame SpriteFrame) []SpriteFrame {
+	return append(frames[:position], append([]SpriteFrame{frame}, frames[position:]...)...)
+}
+
+// AddSpriteFrame inserts a blank frame into the sprite at the position, or at the end when the position is -1.
+func (apj *APJFile) AddSpriteFrame(spriteIndex, position int) error {
+	if spriteIndex < 0 || spriteIndex >= len(apj.Sprites) {
+		return fmt.Errorf("sprite %d does not exist", spriteIndex)
+	}
+	if position == -1 {
+		position = int(apj.Sprites[spriteIndex].Frames)
+	}
+	if position < 0 || position > int(apj.Sprites[spriteIndex].Frames) {
+		return fmt.Errorf("frame position %d is out of range, sprite %d has %d frames", position, spriteIndex, apj.Sprites[spriteIndex].Frames)
+	}
+	if err := apj.checkFrameLimit(); err != nil {
+		return err
+	}
+	return apj.editSpriteFrames(spriteIndex, func(frames []SpriteFrame, size int) []SpriteFrame {
+		return insertFrame(frames, position, SpriteFrame{ImageData: make([]uint8, size)})
+	})
+}
+
+// CopySpriteFrame inserts a copy of a frame of the sprite at the position, or after the frame when the position is -1.
+func (apj *APJFile) CopySpriteFrame(spriteIndex, frame, position int) error {
+	if err := apj.checkSpriteFrame(spriteIndex, frame); err != nil {
+		return err
+	}
+	if position == -1 {
+		position = frame + 1
+	}
+	if position < 0 || position > int(apj.Sprites[spriteIndex].Frames) {
+		return fmt.Errorf("frame position %d is out of range, sprite %d has %d frames", position, spriteIndex, apj.Sprites[spriteIndex].Frames)
+	}
+	if err := apj.checkFrameLimit(); err != nil {
+		return err
+	}
+	return apj.editSpriteFrames(spriteIndex, func(frames []SpriteFrame, size int) []SpriteFrame {
+		copied := SpriteFrame{ImageData: append([]uint8(nil), frames[frame].ImageData...)}
+		return insertFrame(frames, position, copied)
+	})
+}
+
+// RemoveSpriteFrame removes a frame from the sprite. The last frame of a sprite can not be removed.
+func (apj *APJFile) RemoveSpriteFrame(spriteIndex, frame int) error {
+	if err := apj.checkSpriteFrame(spriteIndex, frame); err != nil {
+		return err
+	}
+	if apj.Sprites[spriteIndex].Frames == 1 {
+		return fmt.Errorf("sprite %d only has one frame", spriteIndex)
+	}
+	return apj.editSpriteFrames(spriteIndex, func(frames []SpriteFrame, size int) []SpriteFrame {
+		return append(frames[:frame], frames[frame+1:]...)
+	})
+}
+
+// ReorderSpriteFrames reorders the frames of the sprite, order lists every frame once in its new position.
+func (apj *APJFile) ReorderSpriteFrames(spriteIndex int, order []int) error {
+	if spriteIndex < 0 || spriteIndex >= len(apj.Sprites) {
+		return fmt.Errorf("sprite %d does not exist", spriteIndex)
+	}
+	frames := int(apj.Sprites[spriteIndex].Frames)
+	if len(order) != frames {
+		return fmt.Errorf("order lists %d frames, sprite %d has %d frames", len(order), spriteIndex, frames)
+	}
+	seen := make(map[int]bool)
+	for _, frame := range order {
+		if frame < 0 || frame >= frames || seen[frame] {
+			return fmt.Errorf("order must list each frame from 0 to %d once", frames-1)
+		}
+		seen[frame] = true
+	}
+	return apj.editSpriteFrames(spriteIndex, func(current []SpriteFrame, size int) []SpriteFrame {
+		reordered := make([]SpriteFrame, len(order))
+		for i, frame := range order {
+			reordered[i] = current[frame]
+		}
+		return reordered
+	})
+}
+
+// JoinSprites appends the frames of the other sprites to the first sprite in ids and removes the
+// other sprites. Sprite positions and the IMAGE and SPAWN statements in the event code using a
+// removed sprite are changed to the joined sprite. The code is written to the event files of the
+// project at codePath. It returns the new index of the joined sprite, as the sprites before it
+// move down when a lower sprite is removed.
+func (apj *APJFile) JoinSprites(ids []int, codePath string) (int, error) {
+	if len(ids) < 2 {
+		return 0, fmt.Errorf("at least two sprites are needed to join")
+	}
+	seen := make(map[int]bool)
+	frames := 0
+	for _, id := range ids {
+		if id < 0 || id >= len(apj.Sprites) {
+			return 0, fmt.Errorf("sprite %d does not exist", id)
+		}
+		if seen[id] {
+			return 0, fmt.Errorf("sprite %d is listed more than once", id)
+		}
+		seen[id] = true
+		frames += int(apj.Sprites[id].Frames)
+	}
+	if frames > 255 {
+		return 0, fmt.Errorf("the joined sprite would have %d frames, the maximum is 255", frames)
+	}
+
+	target := &apj.Sprites[ids[0]]
+	target.padFrames()
+	replacements := make(map[int]int)
+	for _, id := range ids[1:] {
+		other := apj.Sprites[id]
+		other.padFrames()
+		otherPlatforms := other.platformFrames()
+		for i, platform := range target.platformFrames() {
+			for _, frame := range *otherPlatforms[i] {
+				*platform = append(*platform, SpriteFrame{ImageData: append([]uint8(nil), frame.ImageData...)})
+			}
+		}
+		replacements[id] = ids[0]
+	}
+	target.renumberFrames()
+
+	mapping, _ := apj.replaceSprites(replacements)
+	codeMappings := make(map[*regexp.Regexp][]int)
+	for _, pattern := range codeImagePatterns {
+		codeMappings[pattern] = mapping
+	}
+	return mapping[ids[0]], apj.remapEventCode(codePath, codeMappings)
+}
+
+// SplitSprite turns each frame of a sprite into its own sprite, inserted after the sprite.
+// The sprite keeps its first frame. Sprite positions and the IMAGE and SPAWN statements in the
+// event code using the sprites after it are renumbered. The code is written to the event files
+// of the project at codePath. It returns the number of sprites added.
+func (apj *APJFile) SplitSprite(spriteIndex int, codePath string) (int, error) {
+	if spriteIndex < 0 || spriteIndex >= len(apj.Sprites) {
+		return 0, fmt.Errorf("sprite %d does not exist", spriteIndex)
+	}
+	sprite := &apj.Sprites[spriteIndex]
+	added := int(sprite.Frames) - 1
+	if added < 1 {
+		return 0, fmt.Errorf("sprite %d only has one frame", spriteIndex)
+	}
+	if len(apj.Sprites)+added > 255 {
+		return 0, fmt.Errorf("splitting sprite %d would give %d sprites, the maximum is 255", spriteIndex, len(apj.Sprites)+added)
+	}
+
+	sprite.padFrames()
+	newSprites := make([]Sprite, added)
+	for i := range newSprites {
+		newSprites[i] = apj.createSprite(0, 0, 1)
+		newPlatforms := newSprites[i].platformFrames()
+		for j, platform := range sprite.platformFrames() {
+			*newPlatforms[j] = append(*newPlatforms[j], SpriteFrame{ImageData: (*platform)[i+1].ImageData})
+		}
+	}
+	for _, platform := range sprite.platformFrames() {
+		*platform = (*platform)[:1]
+	}
+	sprite.renumberFrames()
+
+	// Sprites after the split sprite move up by the number of sprites added
+	mapping := make([]int, len(apj.Sprites))
+	for i := range mapping {
+		mapping[i] = i
+		if i > spriteIndex {
+			mapping[i] = i + added
+		}
+	}
+	for i, spritePos := range apj.SpriteInfo {
+		if int(spritePos.Image) < len(mapping) {
+			apj.SpriteInfo[i].Image = uint8(mapping[spritePos.Image])
+		}
+	}
+
+	apj.Sprites = append(apj.Sprites[:spriteIndex+1], append(newSprites, apj.Sprites[spriteIndex+1:]...)...)
+	for i := range apj.Sprites {
+		apj.Sprites[i].SpriteID = uint8(i)
+	}
+	apj.NrOfSprites = uint8(len(apj.Sprites))
+	apj.CalcOffset()
+	codeMappings := make(map[*regexp.Regexp][]int)
+	for _, pattern := range codeImagePatterns {
+		codeMappings[pattern] = mapping
+	}
+	return added, apj.remapEventCode(codePath, codeMappings)
+}
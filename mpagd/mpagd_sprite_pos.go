@@ -94,38 +94,37 @@ func (apj *APJFile) readSpritePos(f io.Reader) error {
 }
 
 // writeSpritePos writes sprite position data to a binary file.
+// The sprites of each screen are followed by an end of screen marker (0xFF), including screens
+// without sprites, as readSpritePos reads one marker for every screen. A project without screens
+// still gets a single marker, as MPAGD writes one for its blank project.
 func (apj *APJFile) writeSpritePos(f io.Writer) error {
-	currScreen := uint8(0)
-	for _, sprite := range apj.SpriteInfo {
-		// Write screen separator if the screen changes
-		if sprite.Screen != currScreen {
-			currScreen = sprite.Screen
-			if err := binary.Write(f, binary.LittleEndian, uint8(0xFF)); err != nil {
+	nrOfScreens := max(int(apj.NrOfScreens), 1)
+	for screen := 0; screen < nrOfScreens; screen++ {
+		for _, sprite := range apj.SpriteInfo {
+			if int(sprite.Screen) != screen {
+				continue
+			}
+			// Write sprite data
+			if err := binary.Write(f, binary.LittleEndian, sprite.Type); err != nil {
+				return err
+			}
+			if err := binary.Write(f, binary.LittleEndian, sprite.Image); err != nil {
+				return err
+			}
+			if err := binary.Write(f, binary.LittleEndian, sprite.Unknown); err != nil {
+				return err
+			}
+			if err := binary.Write(f, binary.LittleEndian, sprite.X); err != nil {
+				return err
+			}
+			if err := binary.Write(f, binary.LittleEndian, sprite.Y); err != nil {
 				return err
 			}
 		}
-		// Write sprite data
-		if err := binary.Write(f, binary.LittleEndian, sprite.Type); err != nil {
-			return err
-		}
-		if err := binary.Write(f, binary.LittleEndian, sprite.Image); err != nil {
-			return err
-		}
-		if err := binary.Write(f, binary.LittleEndian, sprite.Unknown); err != nil {
-			return err
-		}
-		if err := binary.Write(f, binary.LittleEndian, sprite.X); err != nil {
-			return err
-		}
-		if err := binary.Write(f, binary.LittleEndian, sprite.Y); err != nil {
+		// Write the end of screen marker
+		if err := binary.Write(f, binary.LittleEndian, uint8(0xFF)); err != nil {
 			return err
 		}
 	}
-	// Write final screen separator if there are sprites
-	//if len(apj.SpriteInfo) > 0 {
-	if err := binary.Write(f, binary.LittleEndian, uint8(0xFF)); err != nil {
-		return err
-	}
-	//}
 	return nil
 }
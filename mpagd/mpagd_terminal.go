@@ -0,0 +1,261 @@
+package mpagd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/png"
+	"io"
+	"os"
+	"strings"
+)
+
+// Half block characters used to draw two rows of pixels in each line of the terminal.
+const (
+	upperHalfBlock = "▀"
+	lowerHalfBlock = "▄"
+	fullBlock      = "█"
+)
+
+// WriteTerminalImage writes the image to the terminal with half block characters, two rows of
+// pixels per line. With colour each pixel gets its own 24-bit colour. Without colour, for
+// terminals that have none, black pixels are left clear and any other colour is drawn, so the
+// image should be drawn with the paper in black, see the Mono render options.
+func WriteTerminalImage(w io.Writer, img image.Image, colour bool) error {
+	out := bufio.NewWriter(w)
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += 2 {
+		var lastTop, lastBottom color.RGBA
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			top := color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+			bottom := color.RGBA{A: 255}
+			if y+1 < bounds.Max.Y {
+				bottom = color.RGBAModel.Convert(img.At(x, y+1)).(color.RGBA)
+			}
+
+			if !colour {
+				out.WriteString(monoHalfBlock(isSetPixel(top), isSetPixel(bottom)))
+				continue
+			}
+
+			// Only change the colours when they differ from the previous character
+			if x == bounds.Min.X || top != lastTop {
+				fmt.Fprintf(out, "\x1b[38;2;%d;%d;%dm", top.R, top.G, top.B)
+			}
+			if x == bounds.Min.X || bottom != lastBottom {
+				fmt.Fprintf(out, "\x1b[48;2;%d;%d;%dm", bottom.R, bottom.G, bottom.B)
+			}
+			lastTop, lastBottom = top, bottom
+			out.WriteString(upperHalfBlock)
+		}
+		if colour {
+			out.WriteString("\x1b[0m")
+		}
+		out.WriteString("\n")
+	}
+	return out.Flush()
+}
+
+// isSetPixel returns true when the pixel is drawn in the terminals without colour.
+func isSetPixel(c color.RGBA) bool {
+	return c.A > 0 && (c.R > 0 || c.G > 0 || c.B > 0)
+}
+
+// monoHalfBlock returns the character showing the top and bottom pixels without colour.
+func monoHalfBlock(top, bottom bool) string {
+	switch {
+	case top && bottom:
+		return fullBlock
+	case top:
+		return upperHalfBlock
+	case bottom:
+		return lowerHalfBlock
+	default:
+		return " "
+	}
+}
+
+// InlineProtocol is a terminal graphics protocol used to show images inline.
+type InlineProtocol string
+
+const (
+	InlineAuto  InlineProtocol = "auto"  // Detect the protocol from the environment
+	InlineKitty InlineProtocol = "kitty" // Kitty graphics protocol, also supported by WezTerm and Ghostty
+	InlineSixel InlineProtocol = "sixel" // DEC Sixel graphics
+)
+
+// kittyChunkSize is the largest payload of one Kitty graphics escape sequence.
+const kittyChunkSize = 4096
+
+// ParseInlineProtocol returns the protocol with the given name, an empty name is InlineAuto.
+func ParseInlineProtocol(name string) (InlineProtocol, error) {
+	switch protocol := InlineProtocol(strings.ToLower(strings.TrimSpace(name))); protocol {
+	case "":
+		return InlineAuto, nil
+	case InlineAuto, InlineKitty, InlineSixel:
+		return protocol, nil
+	default:
+		return "", fmt.Errorf("unknown inline image protocol: %s, use auto, kitty or sixel", name)
+	}
+}
+
+// DetectInlineProtocol picks the graphics protocol of the terminal from the environment.
+// It returns an error when the terminal is not known to support either protocol.
+func DetectInlineProtocol() (InlineProtocol, error) {
+	term := strings.ToLower(os.Getenv("TERM"))
+	program := strings.ToLower(os.Getenv("TERM_PROGRAM"))
+	switch {
+	case os.Getenv("KITTY_WINDOW_ID") != "" || strings.Contains(term, "kitty") || strings.Contains(term, "ghostty"):
+		return InlineKitty, nil
+	case program == "wezterm" || program == "ghostty":
+		return InlineKitty, nil
+	case strings.Contains(term, "sixel") || strings.HasPrefix(term, "foot") || strings.HasPrefix(term, "mlterm") || strings.HasPrefix(term, "yaft"):
+		return InlineSixel, nil
+	case program == "iterm.app" || program == "mlterm" || os.Getenv("WT_SESSION") != "":
+		return InlineSixel, nil
+	}
+	return "", fmt.Errorf("no inline image protocol detected for this terminal, use --inline=kitty or --inline=sixel")
+}
+
+// WriteInlineImage writes the image to the terminal with the graphics protocol, each pixel
+// drawn as a square of scale by scale pixels. InlineAuto detects the protocol first.
+func WriteInlineImage(w io.Writer, img image.Image, protocol InlineProtocol, scale int) error {
+	if protocol == InlineAuto {
+		detected, err := DetectInlineProtocol()
+		if err != nil {
+			return err
+		}
+		protocol = detected
+	}
+	scaled := scaleImage(img, max(scale, 1))
+
+	out := bufio.NewWriter(w)
+	switch protocol {
+	case InlineKitty:
+		if err := writeKittyImage(out, scaled); err != nil {
+			return err
+		}
+	case InlineSixel:
+		writeSixelImage(out, scaled)
+	default:
+		return fmt.Errorf("unknown inline image protocol: %s", protocol)
+	}
+	out.WriteString("\n")
+	return out.Flush()
+}
+
+// scaleImage returns a copy of the image with each pixel repeated scale times in both directions.
+func scaleImage(img image.Image, scale int) *image.RGBA {
+	bounds := img.Bounds()
+	scaled := image.NewRGBA(image.Rect(0, 0, bounds.Dx()*scale, bounds.Dy()*scale))
+	for y := 0; y < scaled.Bounds().Dy(); y++ {
+		for x := 0; x < scaled.Bounds().Dx(); x++ {
+			scaled.Set(x, y, img.At(bounds.Min.X+x/scale, bounds.Min.Y+y/scale))
+		}
+	}
+	return scaled
+}
+
+// writeKittyImage sends the image as a PNG with the Kitty graphics protocol, split into chunks.
+func writeKittyImage(out *bufio.Writer, img image.Image) error {
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, img); err != nil {
+		return fmt.Errorf("failed to encode image: %w", err)
+	}
+	payload := base64.StdEncoding.EncodeToString(encoded.Bytes())
+	for start := 0; start < len(payload); start += kittyChunkSize {
+		end := min(start+kittyChunkSize, len(payload))
+		more := 0
+		if end < len(payload) {
+			more = 1
+		}
+		if start == 0 {
+			fmt.Fprintf(out, "\x1b_Ga=T,f=100,m=%d;%s\x1b\\", more, payload[start:end])
+		} else {
+			fmt.Fprintf(out, "\x1b_Gm=%d;%s\x1b\\", more, payload[start:end])
+		}
+	}
+	return nil
+}
+
+// writeSixelImage sends the image as DEC Sixel graphics, six rows of pixels per band with one
+// pass over the band for each colour it uses.
+func writeSixelImage(out *bufio.Writer, img image.Image) {
+	paletted := palettedImage(img)
+	width, height := paletted.Bounds().Dx(), paletted.Bounds().Dy()
+
+	fmt.Fprintf(out, "\x1bPq\"1;1;%d;%d", width, height)
+	for i, c := range paletted.Palette {
+		r, g, b, _ := c.RGBA()
+		fmt.Fprintf(out, "#%d;2;%d;%d;%d", i, r*100/0xffff, g*100/0xffff, b*100/0xffff)
+	}
+	sixels := make([]byte, width)
+	for band := 0; band < height; band += 6 {
+		for index := range paletted.Palette {
+			used := false
+			for x := 0; x < width; x++ {
+				bits := 0
+				for row := 0; row < 6 && band+row < height; row++ {
+					if int(paletted.ColorIndexAt(x, band+row)) == index {
+						bits |= 1 << row
+					}
+				}
+				sixels[x] = byte(63 + bits)
+				used = used || bits != 0
+			}
+			if used {
+				fmt.Fprintf(out, "#%d", index)
+				writeSixelRuns(out, sixels)
+				out.WriteByte('$')
+			}
+		}
+		out.WriteByte('-')
+	}
+	out.WriteString("\x1b\\")
+}
+
+// writeSixelRuns writes the sixels, with repeated sixels run length encoded.
+func writeSixelRuns(out *bufio.Writer, sixels []byte) {
+	for start := 0; start < len(sixels); {
+		end := start
+		for end < len(sixels) && sixels[end] == sixels[start] {
+			end++
+		}
+		if count := end - start; count > 3 {
+			fmt.Fprintf(out, "!%d%c", count, sixels[start])
+		} else {
+			out.Write(sixels[start:end])
+		}
+		start = end
+	}
+}
+
+// palettedImage converts the image to a palette of its own colours. Images with more than
+// 256 colours are dithered to the web safe palette.
+func palettedImage(img image.Image) *image.Paletted {
+	bounds := img.Bounds()
+	var colours color.Palette
+	seen := make(map[color.RGBA]bool)
+	for y := bounds.Min.Y; y < bounds.Max.Y && len(colours) <= 256; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+			if !seen[c] {
+				seen[c] = true
+				colours = append(colours, c)
+			}
+		}
+	}
+	paletted := image.NewPaletted(image.Rect(0, 0, bounds.Dx(), bounds.Dy()), colours)
+	if len(colours) > 256 {
+		paletted.Palette = palette.WebSafe
+		draw.FloydSteinberg.Draw(paletted, paletted.Bounds(), img, bounds.Min)
+		return paletted
+	}
+	draw.Draw(paletted, paletted.Bounds(), img, bounds.Min, draw.Src)
+	return paletted
+}
@@ -45,7 +45,9 @@ type State struct {
 	SpriteInfo     bool
 	Sprites        bool
 	ULAPalette     bool
+	Messages       bool
 	BlocksOffSet   uint8
+	ScreensOffSet  uint8
 }
 
 // APJFile represents the structure of an APJ file with all its components.
@@ -73,6 +75,7 @@ type APJFile struct {
 	Sprites        []Sprite
 	NrOfSprites    uint8
 	ULAPalette     ULAPalette
+	Messages       []Message
 }
 
 func (apj *APJFile) SetNoColorOutput(noColor bool) {
@@ -2,6 +2,7 @@ package mpagd
 
 import (
 	"encoding/binary"
+	"fmt"
 	"io"
 	"strings"
 )
@@ -53,6 +54,24 @@ func (apj *APJFile) ImportULAPalette(lines []string) error {
 	return nil
 }
 
+// exportULAPalette writes the DEFINEPALETTE directive to the given writer.
+// The directive holds the 64 colours of the four ULAplus palettes, but the project only stores
+// the 16 colours of the first one. The other three lines are placeholders repeating them, as in
+// the AGD files written by MPAGD, and ImportULAPalette only reads the first 16 values back.
+func (apj *APJFile) exportULAPalette(f io.Writer) error {
+	for i := 0; i < 4; i++ {
+		directive := ""
+		if i == 0 {
+			directive = "DEFINEPALETTE"
+		}
+		if err := writeAGDLine(f, directive, formatValues(apj.ULAPalette.Colors, "%d")); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintln(f)
+	return err
+}
+
 // readULAPalette reads ULAPlus palette data from an io.Reader.
 // It populates the palette with 16 colors read in little-endian format.
 func (apj *APJFile) readULAPalette(f io.Reader) error {
@@ -0,0 +1,323 @@
+package mpagd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// CodeReference is a sprite image or block used by a line of the event code.
+type CodeReference struct {
+	Event uint8  // Event file number (.aNN)
+	Line  int    // Line number in the event file, starting at 1
+	Value string // Image or block as written in the code, a number or a variable
+	Code  string // The line of code
+}
+
+// UnusedReport lists the blocks and sprites a project does not use.
+type UnusedReport struct {
+	Blocks        []int           // Blocks not used on any screen or by PUTBLOCK
+	Sprites       []int           // Sprite images not placed on any screen or used in the code
+	DynamicImages []CodeReference // Images set from a variable, the sprites they use are unknown
+	DynamicBlocks []CodeReference // Blocks put from a variable, the blocks they use are unknown
+}
+
+// codeImagePatterns match the sprite images used by the event code, the image is the last group.
+var codeImagePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\bIMAGE\s*(?:=|<>|<=|>=|<|>)?\s*(\w+)`),
+	regexp.MustCompile(`\bSPAWN\s+\w+\s+(\w+)`),
+}
+
+// codeBlockPatterns match the blocks used by the event code.
+var codeBlockPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\bPUTBLOCK\s+(\w+)`),
+}
+
+// codeConstants are the AGD constants that can be used as a number, such as LET IMAGE = UP.
+var codeConstants = map[string]int{
+	"LEFT":  0,
+	"RIGHT": 1,
+	"UP":    2,
+	"DOWN":  3,
+}
+
+// codeNumber returns the number of a value in the event code, a number or a constant.
+func codeNumber(value string) (int, bool) {
+	if number, exists := codeConstants[strings.ToUpper(value)]; exists {
+		return number, true
+	}
+	number, err := strconv.Atoi(value)
+	return number, err == nil
+}
+
+// codeLine returns the code part of a line of event code, without its comment.
+func codeLine(line string) string {
+	if i := strings.Index(line, ";"); i >= 0 {
+		return line[:i]
+	}
+	return line
+}
+
+// readEventFiles returns the content of each event file of the project, missing files are skipped.
+func (apj *APJFile) readEventFiles() (map[uint8]string, error) {
+	events := make(map[uint8]string)
+	for id := uint8(0); id < NrOfEvents; id++ {
+		content, err := os.ReadFile(apj.EventFilePath(id))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read event file: %w", err)
+		}
+		events[id] = string(content)
+	}
+	return events, nil
+}
+
+// readEventLines returns the lines of each event file of the project, missing files are skipped.
+func (apj *APJFile) readEventLines() (map[uint8][]string, error) {
+	files, err := apj.readEventFiles()
+	if err != nil {
+		return nil, err
+	}
+	events := make(map[uint8][]string)
+	for id, content := range files {
+		events[id] = splitEventLines(content)
+	}
+	return events, nil
+}
+
+// splitEventLines splits the content of an event file into lines, for LF and CRLF line endings.
+func splitEventLines(content string) []string {
+	return strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+}
+
+// findCodeReferences returns the values matched by the patterns in the event code.
+func findCodeReferences(events map[uint8][]string, patterns []*regexp.Regexp) []CodeReference {
+	var references []CodeReference
+	for id := uint8(0); id < NrOfEvents; id++ {
+		for i, line := range events[id] {
+			for _, pattern := range patterns {
+				for _, match := range pattern.FindAllStringSubmatch(codeLine(line), -1) {
+					references = append(references, CodeReference{Event: id, Line: i + 1, Value: match[len(match)-1], Code: strings.TrimSpace(line)})
+				}
+			}
+		}
+	}
+	return references
+}
+
+// FindDynamicImages returns the IMAGE and SPAWN statements in the event code that use a variable,
+// the sprites they use are unknown so sprites should not be renumbered without asking.
+func (apj *APJFile) FindDynamicImages() ([]CodeReference, error) {
+	return apj.findDynamicReferences(codeImagePatterns)
+}
+
+// FindDynamicBlocks returns the PUTBLOCK statements in the event code that use a variable.
+func (apj *APJFile) FindDynamicBlocks() ([]CodeReference, error) {
+	return apj.findDynamicReferences(codeBlockPatterns)
+}
+
+// findDynamicReferences returns the values matched by the patterns in the event code that are not numbers.
+func (apj *APJFile) findDynamicReferences(patterns []*regexp.Regexp) ([]CodeReference, error) {
+	events, err := apj.readEventLines()
+	if err != nil {
+		return nil, err
+	}
+	dynamic := make([]CodeReference, 0)
+	for _, reference := range findCodeReferences(events, patterns) {
+		if _, ok := codeNumber(reference.Value); !ok {
+			dynamic = append(dynamic, reference)
+		}
+	}
+	return dynamic, nil
+}
+
+// FindImageReferences returns the IMAGE and SPAWN statements in the event code that can not be
+// renumbered to the mapping of old to new sprite IDs.
+func (apj *APJFile) FindImageReferences(mapping []int) ([]CodeReference, error) {
+	return apj.findUnmappedReferences(codeImagePatterns, mapping)
+}
+
+// FindBlockReferences returns the PUTBLOCK statements in the event code that can not be
+// renumbered to the mapping of old to new block IDs.
+func (apj *APJFile) FindBlockReferences(mapping []int) ([]CodeReference, error) {
+	return apj.findUnmappedReferences(codeBlockPatterns, mapping)
+}
+
+// findUnmappedReferences returns the values matched by the patterns in the event code that
+// remapEventCode can not change to the mapping: variables, items the mapping deletes, and
+// constants such as UP for an item that moves.
+func (apj *APJFile) findUnmappedReferences(patterns []*regexp.Regexp, mapping []int) ([]CodeReference, error) {
+	events, err := apj.readEventLines()
+	if err != nil {
+		return nil, err
+	}
+	unmapped := make([]CodeReference, 0)
+	for _, reference := range findCodeReferences(events, patterns) {
+		value, ok := codeNumber(reference.Value)
+		if !ok {
+			unmapped = append(unmapped, reference)
+			continue
+		}
+		if value >= len(mapping) {
+			continue
+		}
+		_, err := strconv.Atoi(reference.Value)
+		if mapping[value] < 0 || (err != nil && mapping[value] != value) {
+			unmapped = append(unmapped, reference)
+		}
+	}
+	return unmapped, nil
+}
+
+// FindUnused lists the blocks that are not used on any screen and the sprites that are not
+// placed on any screen, using the event code files next to the project to find the
+// images and blocks used by IMAGE, SPAWN and PUTBLOCK. Images and blocks given as a
+// variable are listed in the report, as the sprites and blocks they use are unknown.
+func (apj *APJFile) FindUnused() (UnusedReport, error) {
+	report := UnusedReport{Blocks: make([]int, 0), Sprites: make([]int, 0), DynamicImages: make([]CodeReference, 0), DynamicBlocks: make([]CodeReference, 0)}
+	events, err := apj.readEventLines()
+	if err != nil {
+		return report, err
+	}
+
+	usedBlocks := make(map[int]bool)
+	for _, screen := range apj.Screens {
+		for _, row := range screen.ScreenData {
+			for _, blockID := range row {
+				usedBlocks[int(blockID)] = true
+			}
+		}
+	}
+	for _, reference := range findCodeReferences(events, codeBlockPatterns) {
+		if blockID, ok := codeNumber(reference.Value); ok {
+			usedBlocks[blockID] = true
+		} else {
+			report.DynamicBlocks = append(report.DynamicBlocks, reference)
+		}
+	}
+
+	usedSprites := make(map[int]bool)
+	for _, spritePos := range apj.SpriteInfo {
+		usedSprites[int(spritePos.Image)] = true
+	}
+	for _, reference := range findCodeReferences(events, codeImagePatterns) {
+		if image, ok := codeNumber(reference.Value); ok {
+			usedSprites[image] = true
+		} else {
+			report.DynamicImages = append(report.DynamicImages, reference)
+		}
+	}
+
+	for i := range apj.Blocks {
+		if !usedBlocks[i] {
+			report.Blocks = append(report.Blocks, i)
+		}
+	}
+	for i := range apj.Sprites {
+		if !usedSprites[i] {
+			report.Sprites = append(report.Sprites, i)
+		}
+	}
+	return report, nil
+}
+
+// PurgeUnused deletes the unused blocks when purgeBlocks is true, and the unused sprites when
+// purgeSprites is true, then renumbers the blocks and images used by PUTBLOCK, IMAGE and SPAWN
+// in the event code. The code is written to the event files of the project at codePath.
+func (apj *APJFile) PurgeUnused(report UnusedReport, purgeBlocks, purgeSprites bool, codePath string) error {
+	codeMappings := make(map[*regexp.Regexp][]int)
+	if purgeBlocks && len(report.Blocks) > 0 {
+		substitute := 0
+		for substitute < len(apj.Blocks) && containsInt(report.Blocks, substitute) {
+			substitute++
+		}
+		mapping := deleteMapping(len(apj.Blocks), report.Blocks)
+		if _, err := apj.deleteBlocks(report.Blocks, substitute); err != nil {
+			return fmt.Errorf("failed to delete blocks: %w", err)
+		}
+		for _, pattern := range codeBlockPatterns {
+			codeMappings[pattern] = mapping
+		}
+	}
+	if purgeSprites && len(report.Sprites) > 0 {
+		mapping := deleteMapping(len(apj.Sprites), report.Sprites)
+		if _, _, err := apj.deleteSprites(report.Sprites, false); err != nil {
+			return fmt.Errorf("failed to delete sprites: %w", err)
+		}
+		for _, pattern := range codeImagePatterns {
+			codeMappings[pattern] = mapping
+		}
+	}
+	return apj.remapEventCode(codePath, codeMappings)
+}
+
+// deleteMapping returns the new index of each of count items after the ids are deleted, -1 for a deleted item.
+func deleteMapping(count int, ids []int) []int {
+	mapping := make([]int, count)
+	next := 0
+	for i := range mapping {
+		if containsInt(ids, i) {
+			mapping[i] = -1
+			continue
+		}
+		mapping[i] = next
+		next++
+	}
+	return mapping
+}
+
+// containsInt returns true when the value is in the slice.
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// remapEventCode changes the numbers matched by each pattern in the event code from their
+// old value to mapping[old value], and writes the code to the event files of the project at codePath.
+// Only the files that differ from the ones at codePath are written, and each keeps its line endings.
+func (apj *APJFile) remapEventCode(codePath string, codeMappings map[*regexp.Regexp][]int) error {
+	files, err := apj.readEventFiles()
+	if err != nil {
+		return err
+	}
+	target := NewAPJFile(codePath)
+	for id, content := range files {
+		lines := splitEventLines(content)
+		for i, line := range lines {
+			code := codeLine(line)
+			for pattern, mapping := range codeMappings {
+				code = pattern.ReplaceAllStringFunc(code, func(match string) string {
+					groups := pattern.FindStringSubmatchIndex(match)
+					start, end := groups[len(groups)-2], groups[len(groups)-1]
+					value, err := strconv.Atoi(match[start:end])
+					if err != nil || value < 0 || value >= len(mapping) || mapping[value] < 0 {
+						return match
+					}
+					return match[:start] + strconv.Itoa(mapping[value]) + match[end:]
+				})
+			}
+			lines[i] = code + line[len(codeLine(line)):]
+		}
+
+		lineEnding := "\n"
+		if strings.Contains(content, "\r\n") {
+			lineEnding = "\r\n"
+		}
+		remapped := strings.Join(lines, lineEnding)
+		if existing, err := os.ReadFile(target.EventFilePath(id)); err == nil && string(existing) == remapped {
+			continue
+		}
+		if err := os.WriteFile(target.EventFilePath(id), []byte(remapped), 0644); err != nil {
+			return fmt.Errorf("failed to write event file: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package mpagd
+
+import (
+	"fmt"
+)
+
+// ValidationIssue describes a problem found in a project.
+type ValidationIssue struct {
+	Level   string `json:"level"`   // "error" or "warning"
+	Section string `json:"section"` // Project section the problem was found in
+	Message string `json:"message"` // Description of the problem
+}
+
+// Validate checks the project against a list of rules and returns the problems found.
+// Errors are problems that break the game or the tools, warnings are worth a look.
+func (apj *APJFile) Validate() []ValidationIssue {
+	validators := []func() []ValidationIssue{
+		apj.validateCounts,
+		apj.validateBlocks,
+		apj.validateSprites,
+		apj.validateScreens,
+		apj.validateSpritePositions,
+		apj.validateMap,
+	}
+
+	issues := make([]ValidationIssue, 0)
+	for _, validator := range validators {
+		issues = append(issues, validator()...)
+	}
+	return issues
+}
+
+// HasValidationErrors returns true when any of the issues is an error.
+func HasValidationErrors(issues []ValidationIssue) bool {
+	for _, issue := range issues {
+		if issue.Level == "error" {
+			return true
+		}
+	}
+	return false
+}
+
+// validationError creates an error level issue.
+func validationError(section, format string, args ...interface{}) ValidationIssue {
+	return ValidationIssue{Level: "error", Section: section, Message: fmt.Sprintf(format, args...)}
+}
+
+// validationWarning creates a warning level issue.
+func validationWarning(section, format string, args ...interface{}) ValidationIssue {
+	return ValidationIssue{Level: "warning", Section: section, Message: fmt.Sprintf(format, args...)}
+}
+
+// validateCounts checks that the stored counts match the data and stay within the uint8 limits.
+func (apj *APJFile) validateCounts() []ValidationIssue {
+	var issues []ValidationIssue
+	counts := []struct {
+		section string
+		stored  uint8
+		actual  int
+	}{
+		{"Blocks", apj.NrOfBlocks, len(apj.Blocks)},
+		{"Sprites", apj.NrOfSprites, len(apj.Sprites)},
+		{"Screens", apj.NrOfScreens, len(apj.Screens)},
+		{"Objects", apj.NrOfObjects, len(apj.Objects)},
+	}
+	for _, count := range counts {
+		if count.actual > 255 {
+			issues = append(issues, validationError(count.section, "%d %s, the maximum is 255", count.actual, count.section))
+		} else if int(count.stored) != count.actual {
+			issues = append(issues, validationError(count.section, "count is %d but the project has %d", count.stored, count.actual))
+		}
+	}
+	if len(apj.Messages) > 255 {
+		issues = append(issues, validationError("Messages", "%d messages, the maximum is 255", len(apj.Messages)))
+	}
+	return issues
+}
+
+// validateBlocks checks the size of the block data.
+func (apj *APJFile) validateBlocks() []ValidationIssue {
+	var issues []ValidationIssue
+	for i, block := range apj.Blocks {
+		if len(block.Spectrum) != 9 {
+			issues = append(issues, validationError("Blocks", "block %d has %d bytes of Spectrum data, expected 9", i, len(block.Spectrum)))
+		}
+		if _, exists := IdToBlockType[block.Type]; !exists {
+			issues = append(issues, validationWarning("Blocks", "block %d has an unknown block type %d", i, block.Type))
+		}
+	}
+	return issues
+}
+
+// validateSprites checks the sprite frames and that the frame offsets fit in a uint8.
+func (apj *APJFile) validateSprites() []ValidationIssue {
+	var issues []ValidationIssue
+	totalFrames := 0
+	for i, sprite := range apj.Sprites {
+		totalFrames += int(sprite.Frames)
+		if int(sprite.Frames) != len(sprite.Spectrum) {
+			issues = append(issues, validationError("Sprites", "sprite %d has %d frames but %d frames of Spectrum data", i, sprite.Frames, len(sprite.Spectrum)))
+		}
+		if sprite.Frames == 0 {
+			issues = append(issues, validationError("Sprites", "sprite %d has no frames", i))
+		}
+		for _, frame := range sprite.Spectrum {
+			if len(frame.ImageData) != 32 {
+				issues = append(issues, validationError("Sprites", "sprite %d frame %d has %d bytes of Spectrum data, expected 32", i, frame.Frame, len(frame.ImageData)))
+			}
+		}
+	}
+	if totalFrames > 255 {
+		issues = append(issues, validationError("Sprites", "%d sprite frames, the maximum is 255", totalFrames))
+	}
+	return issues
+}
+
+// validateScreens checks the screen sizes against the window and that every cell is an existing block.
+func (apj *APJFile) validateScreens() []ValidationIssue {
+	var issues []ValidationIssue
+	for i, screen := range apj.Screens {
+		if len(screen.ScreenData) != int(apj.Windows.Height) {
+			issues = append(issues, validationError("Screens", "screen %d has %d rows, the window height is %d", i, len(screen.ScreenData), apj.Windows.Height))
+		}
+		for row, data := range screen.ScreenData {
+			if len(data) != int(apj.Windows.Width) {
+				issues = append(issues, validationError("Screens", "screen %d row %d has %d columns, the window width is %d", i, row, len(data), apj.Windows.Width))
+			}
+			for col, blockID := range data {
+				if int(blockID) >= len(apj.Blocks) {
+					issues = append(issues, validationError("Screens", "screen %d row %d column %d uses block %d, the project has %d blocks", i, row, col, blockID, len(apj.Blocks)))
+				}
+			}
+		}
+	}
+	return issues
+}
+
+// validateSpritePositions checks that sprite positions use existing sprites and screens and are inside the window.
+// The positions are stored like SPRITEPOSITION, the X field holds the vertical position.
+func (apj *APJFile) validateSpritePositions() []ValidationIssue {
+	var issues []ValidationIssue
+	top, left := int(apj.Windows.Top)*8, int(apj.Windows.Left)*8
+	bottom, right := top+int(apj.Windows.Height)*8-16, left+int(apj.Windows.Width)*8-16
+	for i, sprite := range apj.SpriteInfo {
+		if sprite.Image >= apj.NrOfSprites {
+			issues = append(issues, validationError("SpriteInfo", "sprite position %d on screen %d uses sprite image %d, the project has %d sprites", i, sprite.Screen, sprite.Image, apj.NrOfSprites))
+		}
+		if sprite.Screen >= apj.NrOfScreens {
+			issues = append(issues, validationError("SpriteInfo", "sprite position %d is on screen %d, the project has %d screens", i, sprite.Screen, apj.NrOfScreens))
+		}
+		if int(sprite.X) < top || int(sprite.X) > bottom || int(sprite.Y) < left || int(sprite.Y) > right {
+			issues = append(issues, validationWarning("SpriteInfo", "sprite position %d on screen %d at %d,%d is outside the window", i, sprite.Screen, sprite.Y, sprite.X))
+		}
+	}
+	return issues
+}
+
+// validateMap checks that every map cell is an existing screen or empty (255).
+func (apj *APJFile) validateMap() []ValidationIssue {
+	var issues []ValidationIssue
+	for row, data := range apj.Map.Map {
+		for col, screen := range data {
+			if screen != 255 && screen >= apj.NrOfScreens {
+				issues = append(issues, validationError("Map", "map row %d column %d uses screen %d, the project has %d screens", row, col, screen, apj.NrOfScreens))
+			}
+		}
+	}
+	if len(apj.Map.Map) > 0 && apj.Map.StartScreen >= apj.NrOfScreens {
+		issues = append(issues, validationError("Map", "start screen %d does not exist, the project has %d screens", apj.Map.StartScreen, apj.NrOfScreens))
+	}
+	return issues
+}
@@ -2,6 +2,7 @@ package mpagd
 
 import (
 	"encoding/binary"
+	"fmt"
 	"io"
 	"regexp"
 )
@@ -95,3 +96,13 @@ func (apj *APJFile) writeWindows(f io.Writer) error {
 	}
 	return nil
 }
+
+// exportWindows writes the DEFINEWINDOW directive to the given writer.
+func (apj *APJFile) exportWindows(f io.Writer) error {
+	data := fmt.Sprintf("%d %d %d %d", apj.Windows.Top, apj.Windows.Left, apj.Windows.Height, apj.Windows.Width)
+	if err := writeAGDLine(f, "DEFINEWINDOW", data); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(f)
+	return err
+}
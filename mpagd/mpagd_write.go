@@ -1,6 +1,8 @@
 package mpagd
 
 import (
+	"bufio"
+	"io"
 	"os"
 )
 
@@ -75,5 +77,46 @@ func (apj *APJFile) WriteAPJ(outputFilePath string) error {
 		return err
 	}
 
-	return nil
+	// Messages are not part of the APJ file, they are kept in their own file
+	return apj.writeMessages(outputFilePath)
+}
+
+// ExportAGD writes the project as an AGD source file, including any .aNN event files
+func (apj *APJFile) ExportAGD(agdFilePath string) error {
+	// Ensure the output directory exists
+	if err := ensureDirExists(agdFilePath); err != nil {
+		return err
+	}
+
+	// Create the output file
+	file, err := os.Create(agdFilePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := bufio.NewWriter(file)
+
+	// Sequentially write all sections in the order MPAGD exports them
+	exporters := []func(io.Writer) error{
+		apj.exportWindows,
+		apj.exportKeys,
+		apj.exportBlocks,
+		apj.exportSprites,
+		apj.exportObjects,
+		apj.exportScreens,
+		apj.exportMap,
+		apj.exportFont,
+		apj.exportULAPalette,
+		apj.exportMessages,
+		apj.exportEvents,
+	}
+
+	for _, exporter := range exporters {
+		if err := exporter(w); err != nil {
+			return err
+		}
+	}
+
+	return w.Flush()
 }
@@ -1,11 +1,20 @@
 package tests
 
 import (
+	"archive/tar"
+	"bytes"
+	"encoding/base64"
 	"fmt"
+	"image"
+	"image/gif"
+	"image/png"
 	"io"
+	"math/bits"
 	"os"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/Mrpye/mpagd_util/cmd"
 	"github.com/Mrpye/mpagd_util/mpagd"
@@ -117,7 +126,7 @@ func TestImportWriteCompare(t *testing.T) {
 	agdFilePath := "testproject.agd"
 	opt := mpagd.CreateImportOptions()
 	opt.SetOwOptionsTrue()
-	opt.SetIgnoreOptions(false, false, false, false, false, false, false, false, false)
+	opt.SetIgnoreOptions(false, false, false, false, false, false, false, false, false, false)
 	apjFile.ImportAGD(agdFilePath, opt)
 
 	// // Example comparison
@@ -134,6 +143,1620 @@ func TestImportWriteCompare(t *testing.T) {
 	CleanOutputFolder()
 }
 
+// TestExportAGDRoundTrip tests that an exported AGD file imports back to the same project data
+func TestExportAGDRoundTrip(t *testing.T) {
+	CleanOutputFolder()
+	args := []string{"project", "import", "output/output.apj", "testproject.agd"}
+	executeCommand(t, cmd.RootCmd, args, "AGD elements imported successfully")
+
+	args = []string{"project", "export-agd", "output/output.apj", "output/output.agd"}
+	executeCommand(t, cmd.RootCmd, args, "Project exported successfully")
+
+	apjFile := mpagd.NewAPJFile("output/output.apj")
+	if err := apjFile.ReadAPJ(); err != nil {
+		t.Fatalf("Error reading APJ file: %v", err)
+	}
+
+	otherAPJFile := mpagd.NewAPJFile("output/other.apj")
+	opt := mpagd.CreateImportOptions()
+	opt.SetOwOptionsTrue()
+	opt.SetIgnoreOptionsFalse()
+	if err := otherAPJFile.ImportAGD("output/output.agd", opt); err != nil {
+		t.Fatalf("Error importing exported AGD file: %v", err)
+	}
+
+	differences := apjFile.CompareData(otherAPJFile)
+	if len(differences) > 0 {
+		t.Errorf("Differences found: %+v", differences)
+	}
+	CleanOutputFolder()
+}
+
+// TestImportObjects tests that every DEFINEOBJECT in an AGD file is imported and exported
+func TestImportObjects(t *testing.T) {
+	CleanOutputFolder()
+	agd, err := os.ReadFile("testproject.agd")
+	if err != nil {
+		t.Fatalf("Error reading AGD file: %v", err)
+	}
+	second := "DEFINEOBJECT    5 3 40 64\n" + strings.Repeat("                255 0 255 0 255 0 255 0 255 0 255 0 255 0 255 0\n", 2) + "\n"
+	content := strings.Replace(string(agd), "DEFINEOBJECT", second+"DEFINEOBJECT", 1)
+	if err := os.WriteFile("output/objects.agd", []byte(content), 0644); err != nil {
+		t.Fatalf("Error writing AGD file: %v", err)
+	}
+
+	args := []string{"project", "import", "output/output.apj", "output/objects.agd", "--no-code"}
+	executeCommand(t, cmd.RootCmd, args, "AGD elements imported successfully")
+	args = []string{"project", "export-agd", "output/output.apj", "output/output.agd"}
+	executeCommand(t, cmd.RootCmd, args, "Project exported successfully")
+
+	otherAPJFile := mpagd.NewAPJFile("output/other.apj")
+	opt := mpagd.CreateImportOptions()
+	opt.SetOwOptionsTrue()
+	opt.SetIgnoreOptionsFalse()
+	if err := otherAPJFile.ImportAGD("output/output.agd", opt); err != nil {
+		t.Fatalf("Error importing exported AGD file: %v", err)
+	}
+	apjFile := mpagd.NewAPJFile("output/output.apj")
+	if err := apjFile.ReadAPJ(); err != nil {
+		t.Fatalf("Error reading APJ file: %v", err)
+	}
+	for _, apjFile := range []*mpagd.APJFile{apjFile, otherAPJFile} {
+		if len(apjFile.Objects) != 2 {
+			t.Fatalf("Expected 2 objects, got %d", len(apjFile.Objects))
+		}
+		if fmt.Sprint(apjFile.Objects[0].Spectrum[:5]) != "[5 3 40 64 255]" || fmt.Sprint(apjFile.Objects[1].Spectrum[:4]) != "[71 254 8 8]" || apjFile.Objects[1].ID != 1 {
+			t.Errorf("Unexpected objects: %v, %v", apjFile.Objects[0].Spectrum[:5], apjFile.Objects[1].Spectrum[:4])
+		}
+	}
+	CleanOutputFolder()
+}
+
+// TestMessages tests the message import, edit and reorder commands
+func TestMessages(t *testing.T) {
+	CleanOutputFolder()
+	args := []string{"project", "import", "output/output.apj", "testproject.agd", "--no-code=false"}
+	executeCommand(t, cmd.RootCmd, args, "AGD elements imported successfully")
+
+	args = []string{"messages", "list", "output/output.apj"}
+	executeCommand(t, cmd.RootCmd, args, "\"By Andrew Pye\"")
+
+	args = []string{"messages", "add", "output/output.apj", "NEW MESSAGE", "--index", "0"}
+	executeCommand(t, cmd.RootCmd, args, "Message 0 added successfully")
+
+	// The messages change the event code, so it is backed up with the project
+	backups, err := mpagd.NewAPJFile("output/output.apj").ListBackupProjectFiles("output/backups")
+	if err != nil || len(backups) != 1 {
+		t.Fatalf("Expected 1 backup, got %v, %v", backups, err)
+	}
+	if _, ok := readBackup(t, "output/backups/"+backups[0])["output.a00"]; !ok {
+		t.Errorf("Expected the code files in the backup")
+	}
+
+	args = []string{"messages", "delete", "output/output.apj", "2"}
+	executeCommand(t, cmd.RootCmd, args, "Message 2 deleted successfully")
+
+	apjFile := mpagd.NewAPJFile("output/output.apj")
+	if err := apjFile.ReadAPJ(); err != nil {
+		t.Fatalf("Error reading APJ file: %v", err)
+	}
+	if len(apjFile.Messages) != 13 {
+		t.Fatalf("Expected 13 messages, got %d", len(apjFile.Messages))
+	}
+	if apjFile.Messages[0].Text != "NEW MESSAGE" || apjFile.Messages[1].Text != "SPLAT" || apjFile.Messages[2].Text != "SCORE" {
+		t.Errorf("Unexpected messages: %+v", apjFile.Messages[:3])
+	}
+
+	// The event code follows the messages, the title message 0 is now message 1
+	var code strings.Builder
+	for id := uint8(0); id < mpagd.NrOfEvents; id++ {
+		data, _ := os.ReadFile(apjFile.EventFilePath(id))
+		code.Write(data)
+	}
+	if strings.Contains(code.String(), "MESSAGE 0 ") || !strings.Contains(code.String(), "MESSAGE 1               ; display the game title") {
+		t.Errorf("Expected MESSAGE 0 to be renumbered to MESSAGE 1 in the event code")
+	}
+
+	// Deleting a message the code still displays warns about the lines using it
+	args = []string{"messages", "delete", "output/output.apj", "1"}
+	executeCommand(t, cmd.RootCmd, args, "displays the message 1: MESSAGE 1               ; display the game title")
+	CleanOutputFolder()
+}
+
+// TestImportMessagesComment tests that a quote in the comment of a message line is not part of the message
+func TestImportMessagesComment(t *testing.T) {
+	apjFile := mpagd.NewAPJFile("output/output.apj")
+	lines := []string{"DEFINEMESSAGES", `"SPLAT"          ;0 "splat"`, `"GAME OVER"      ;1`}
+	if err := apjFile.ImportMessages(lines); err != nil {
+		t.Fatalf("Error importing messages: %v", err)
+	}
+	if len(apjFile.Messages) != 2 || apjFile.Messages[0].Text != "SPLAT" || apjFile.Messages[1].Text != "GAME OVER" {
+		t.Errorf("Expected the messages SPLAT and GAME OVER, got %v", apjFile.Messages)
+	}
+}
+
+// TestMessagesEventFiles tests that only the event files using a renumbered message are written,
+// keeping their line endings
+func TestMessagesEventFiles(t *testing.T) {
+	CleanOutputFolder()
+	args := []string{"project", "import", "output/output.apj", "testproject.agd", "--no-code=false"}
+	executeCommand(t, cmd.RootCmd, args, "21 event code files written")
+	apjFile := mpagd.NewAPJFile("output/output.apj")
+
+	// Use CRLF line endings in the first event file using a message and date every file in the past
+	past := time.Now().Add(-time.Hour).Truncate(time.Second)
+	crlf := -1
+	for id := uint8(0); id < mpagd.NrOfEvents; id++ {
+		path := apjFile.EventFilePath(id)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		if crlf < 0 && strings.Contains(string(data), "MESSAGE") {
+			crlf = int(id)
+			data = []byte(strings.ReplaceAll(string(data), "\n", "\r\n"))
+			if err := os.WriteFile(path, data, 0644); err != nil {
+				t.Fatalf("Error writing event file: %v", err)
+			}
+		}
+		if err := os.Chtimes(path, past, past); err != nil {
+			t.Fatalf("Error dating event file: %v", err)
+		}
+	}
+	if crlf < 0 {
+		t.Fatalf("Expected an event file using a message")
+	}
+	modified := func() []uint8 {
+		var ids []uint8
+		for id := uint8(0); id < mpagd.NrOfEvents; id++ {
+			if info, err := os.Stat(apjFile.EventFilePath(id)); err == nil && info.ModTime().After(past) {
+				ids = append(ids, id)
+			}
+		}
+		return ids
+	}
+
+	// Adding a message at the end does not renumber any message
+	args = []string{"messages", "add", "output/output.apj", "LAST MESSAGE", "--index", "-1"}
+	executeCommand(t, cmd.RootCmd, args, "added successfully")
+	if ids := modified(); len(ids) != 0 {
+		t.Errorf("Expected no event files to be written, got %v", ids)
+	}
+
+	// Adding a message at the start renumbers the messages, the CRLF file keeps its line endings
+	args = []string{"messages", "add", "output/output.apj", "FIRST MESSAGE", "--index", "0"}
+	executeCommand(t, cmd.RootCmd, args, "Message 0 added successfully")
+	if ids := modified(); len(ids) == 0 || len(ids) == int(mpagd.NrOfEvents) {
+		t.Errorf("Expected only the event files using a message to be written, got %v", ids)
+	}
+	data, err := os.ReadFile(apjFile.EventFilePath(uint8(crlf)))
+	if err != nil {
+		t.Fatalf("Error reading event file: %v", err)
+	}
+	if strings.Count(string(data), "\r\n") != strings.Count(string(data), "\n") {
+		t.Errorf("Expected event file %d to keep its CRLF line endings", crlf)
+	}
+	CleanOutputFolder()
+}
+
+// TestImportEvents tests that the AGD events are written to the project's code files
+func TestImportEvents(t *testing.T) {
+	CleanOutputFolder()
+	args := []string{"project", "import", "output/output.apj", "testproject.agd", "--no-code=false"}
+	executeCommand(t, cmd.RootCmd, args, "21 event code files written")
+
+	for id, name := range mpagd.IdToEventName {
+		data, err := os.ReadFile(fmt.Sprintf("output/output.a%02d", id))
+		if err != nil {
+			t.Fatalf("Error reading event file: %v", err)
+		}
+		if !strings.HasPrefix(string(data), "EVENT "+name+"\n") {
+			t.Errorf("Event file %02d does not start with EVENT %s", id, name)
+		}
+	}
+	CleanOutputFolder()
+}
+
+// readBackup returns the contents of each file in a backup archive
+func readBackup(t *testing.T, path string) map[string][]byte {
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Error opening backup: %v", err)
+	}
+	defer file.Close()
+	files := make(map[string][]byte)
+	reader := tar.NewReader(file)
+	for header, err := reader.Next(); err == nil; header, err = reader.Next() {
+		files[header.Name], _ = io.ReadAll(reader)
+	}
+	return files
+}
+
+// TestImportBackup tests the backups made by an import, the project before an in place import
+// and the code files next to the output project before they are replaced
+func TestImportBackup(t *testing.T) {
+	CleanOutputFolder()
+	args := []string{"project", "import", "output/output.apj", "testproject.agd", "--no-code=false", "--overwrite-code=false", "--replace=false"}
+	executeCommand(t, cmd.RootCmd, args, "21 event code files written")
+	original, err := os.ReadFile("output/output.apj")
+	if err != nil {
+		t.Fatalf("Error reading APJ file: %v", err)
+	}
+
+	executeCommand(t, cmd.RootCmd, args, "AGD elements imported successfully")
+	backups, err := mpagd.NewAPJFile("output/output.apj").ListBackupProjectFiles("output/backups")
+	if err != nil {
+		t.Fatalf("Error listing backups: %v", err)
+	}
+	if len(backups) != 1 {
+		t.Fatalf("Expected 1 backup, got %v", backups)
+	}
+	if !bytes.Equal(readBackup(t, "output/backups/"+backups[0])["output.apj"], original) {
+		t.Errorf("Expected the backup to hold the project before the import")
+	}
+
+	// Replacing the code files of another output project backs them up first
+	args = []string{"project", "import", "output/output.apj", "testproject.agd", "output/other.apj", "--no-code=false", "--overwrite-code=false", "--replace=false"}
+	executeCommand(t, cmd.RootCmd, args, "21 event code files written")
+	if err := os.WriteFile("output/other.a00", []byte("; edited"), 0644); err != nil {
+		t.Fatalf("Error writing event file: %v", err)
+	}
+	args = []string{"project", "import", "output/output.apj", "testproject.agd", "output/other.apj", "--no-code=false", "--overwrite-code", "--replace=false"}
+	executeCommand(t, cmd.RootCmd, args, "21 event code files written")
+	if backups, err = mpagd.NewAPJFile("output/other.apj").ListBackupProjectFiles("output/backups"); err != nil || len(backups) != 2 || !strings.HasPrefix(backups[0], "backup_other_") {
+		t.Fatalf("Expected a backup of the other project, got %v, %v", backups, err)
+	}
+	if code := readBackup(t, "output/backups/"+backups[0])["other.a00"]; string(code) != "; edited" {
+		t.Errorf("Expected the backup to hold the code file before the import, got %q", code)
+	}
+	CleanOutputFolder()
+}
+
+// TestBackupSameSecond tests that backups made in quick succession are all kept
+func TestBackupSameSecond(t *testing.T) {
+	CleanOutputFolder()
+	args := []string{"project", "import", "output/output.apj", "testproject.agd", "--no-code"}
+	executeCommand(t, cmd.RootCmd, args, "AGD elements imported successfully")
+
+	apjFile := mpagd.NewAPJFile("output/output.apj")
+	for i := 0; i < 3; i++ {
+		if err := apjFile.BackupProjectFile(false); err != nil {
+			t.Fatalf("Error creating backup: %v", err)
+		}
+	}
+	backups, err := apjFile.ListBackupProjectFiles("output/backups")
+	if err != nil {
+		t.Fatalf("Error listing backups: %v", err)
+	}
+	if len(backups) != 3 {
+		t.Errorf("Expected 3 backups, got %v", backups)
+	}
+	CleanOutputFolder()
+}
+
+// TestImportMap tests the map import and the screen remapping when screens are appended
+func TestImportMap(t *testing.T) {
+	CleanOutputFolder()
+	args := []string{"project", "import", "output/output.apj", "testproject.agd", "--no-code"}
+	executeCommand(t, cmd.RootCmd, args, "AGD elements imported successfully")
+
+	apjFile := mpagd.NewAPJFile("output/output.apj")
+	if err := apjFile.ReadAPJ(); err != nil {
+		t.Fatalf("Error reading APJ file: %v", err)
+	}
+	if apjFile.Map.Width != 3 || apjFile.Map.Height != 6 || apjFile.Map.Map[1][1] != 3 || apjFile.Map.StartScreen != 0 {
+		t.Errorf("Unexpected map: %+v", apjFile.Map)
+	}
+
+	// Importing only the map, twice, replaces the map as no screens were appended
+	for range 2 {
+		args = []string{"map", "import", "output/output.apj", "testproject.agd", "--replace=false"}
+		executeCommand(t, cmd.RootCmd, args, "Map imported successfully")
+	}
+	imported := mpagd.NewAPJFile("output/output.apj")
+	if err := imported.ReadAPJ(); err != nil {
+		t.Fatalf("Error reading APJ file: %v", err)
+	}
+	if fmt.Sprint(imported.Map) != fmt.Sprint(apjFile.Map) {
+		t.Errorf("Expected the map to be replaced, got %+v", imported.Map)
+	}
+
+	// Append the screens again, the map should point at the new screens
+	args = []string{"project", "import-selective", "output/output.apj", "testproject.agd", "--screens", "--maps"}
+	executeCommand(t, cmd.RootCmd, args, "AGD elements imported successfully")
+
+	apjFile = mpagd.NewAPJFile("output/output.apj")
+	if err := apjFile.ReadAPJ(); err != nil {
+		t.Fatalf("Error reading APJ file: %v", err)
+	}
+	// The appended map is placed to the right of the existing map, after an empty column
+	if len(apjFile.Screens) != 20 || apjFile.Map.Width != 7 || apjFile.Map.Map[1][1] != 3 || apjFile.Map.Map[1][3] != 255 || apjFile.Map.Map[1][5] != 13 || apjFile.Map.StartScreen != 0 {
+		t.Errorf("Unexpected appended map: %+v", apjFile.Map)
+	}
+	if _, changed := imported.CompareData(apjFile)["Map"]; !changed {
+		t.Errorf("Expected CompareData to report the changed map")
+	}
+
+	// Screens past 254 do not fit on the map
+	agd, err := os.ReadFile("testproject.agd")
+	if err != nil {
+		t.Fatalf("Error reading AGD file: %v", err)
+	}
+	start := bytes.Index(agd, []byte("MAP "))
+	end := bytes.Index(agd, []byte("ENDMAP"))
+	agd = append(append(append([]byte{}, agd[:start]...), "MAP WIDTH 1\nSTARTSCREEN 0\n250\n"...), agd[end:]...)
+	if err := os.WriteFile("output/wrap.agd", agd, 0644); err != nil {
+		t.Fatalf("Error writing AGD file: %v", err)
+	}
+	options := mpagd.CreateImportOptions()
+	options.SetIgnoreOptions(true, true, true, true, true, false, false, true, true, true)
+	if err := apjFile.ImportAGD("output/wrap.agd", options); err == nil || !strings.Contains(err.Error(), "would become screen 270") {
+		t.Errorf("Expected an error for a map screen past 254, got %v", err)
+	}
+	CleanOutputFolder()
+}
+
+// TestImportBlocksPNG tests that blocks rendered to a PNG file can be imported again
+func TestImportBlocksPNG(t *testing.T) {
+	CleanOutputFolder()
+	args := []string{"project", "import", "output/output.apj", "testproject.agd", "--no-code"}
+	executeCommand(t, cmd.RootCmd, args, "AGD elements imported successfully")
+
+	args = []string{"blocks", "render-bmp", "output/output.apj", "0", "16", "output/blocks.png"}
+	executeCommand(t, cmd.RootCmd, args, "successfully rendered")
+
+	args = []string{"blocks", "import-png", "output/output.apj", "output/blocks.png", "output/blocks.apj", "--replace", "--gap", "1", "--types", "0-7:WALLBLOCK,8-:LADDERBLOCK"}
+	executeCommand(t, cmd.RootCmd, args, "16 blocks imported successfully")
+
+	args = []string{"blocks", "render-bmp", "output/blocks.apj", "0", "16", "output/blocks2.png"}
+	executeCommand(t, cmd.RootCmd, args, "successfully rendered")
+
+	apjFile := mpagd.NewAPJFile("output/blocks.apj")
+	if err := apjFile.ReadAPJ(); err != nil {
+		t.Fatalf("Error reading APJ file: %v", err)
+	}
+	if len(apjFile.Blocks) != 16 || apjFile.Blocks[7].Type != 2 || apjFile.Blocks[8].Type != 3 {
+		t.Errorf("Unexpected blocks imported: %d", len(apjFile.Blocks))
+	}
+
+	original, _ := os.ReadFile("output/blocks.png")
+	imported, _ := os.ReadFile("output/blocks2.png")
+	if string(original) != string(imported) {
+		t.Errorf("Imported blocks do not match the original blocks")
+	}
+	CleanOutputFolder()
+}
+
+// TestImportSpritesPNG tests that sprites rendered to separate PNG files can be imported again
+func TestImportSpritesPNG(t *testing.T) {
+	CleanOutputFolder()
+	args := []string{"project", "import", "output/output.apj", "testproject.agd", "--no-code"}
+	executeCommand(t, cmd.RootCmd, args, "AGD elements imported successfully")
+
+	args = []string{"sprites", "render-bmp", "output/output.apj", "0", "19", "output/sprites", "--seprate"}
+	executeCommand(t, cmd.RootCmd, args, "successfully rendered")
+
+	args = []string{"sprites", "import-png", "output/output.apj", "output/sprites", "output/sprites.apj", "--replace"}
+	executeCommand(t, cmd.RootCmd, args, "19 sprites imported successfully")
+
+	original := mpagd.NewAPJFile("output/output.apj")
+	if err := original.ReadAPJ(); err != nil {
+		t.Fatalf("Error reading APJ file: %v", err)
+	}
+	imported := mpagd.NewAPJFile("output/sprites.apj")
+	if err := imported.ReadAPJ(); err != nil {
+		t.Fatalf("Error reading APJ file: %v", err)
+	}
+	if len(imported.Sprites) != len(original.Sprites) {
+		t.Fatalf("Expected %d sprites, got %d", len(original.Sprites), len(imported.Sprites))
+	}
+	for i, sprite := range original.Sprites {
+		if sprite.Frames != imported.Sprites[i].Frames || sprite.OffSet != imported.Sprites[i].OffSet {
+			t.Errorf("Sprite %d frames or offset do not match", i)
+			continue
+		}
+		for frame := range sprite.Spectrum {
+			if string(sprite.Spectrum[frame].ImageData) != string(imported.Sprites[i].Spectrum[frame].ImageData) {
+				t.Errorf("Sprite %d frame %d does not match", i, frame)
+			}
+		}
+	}
+	CleanOutputFolder()
+}
+
+// TestImportScreenPNG tests that a rendered screen can be imported again using the existing blocks
+func TestImportScreenPNG(t *testing.T) {
+	CleanOutputFolder()
+	args := []string{"project", "import", "output/output.apj", "testproject.agd", "--no-code"}
+	executeCommand(t, cmd.RootCmd, args, "AGD elements imported successfully")
+
+	args = []string{"screens", "render-bmp", "output/output.apj", "1", "output/screen.png"}
+	executeCommand(t, cmd.RootCmd, args, "Screen rendered successfully")
+
+	args = []string{"screens", "import-png", "output/output.apj", "output/screen.png", "--type", "WALLBLOCK"}
+	executeCommand(t, cmd.RootCmd, args, "Screen 10 imported successfully")
+
+	args = []string{"screens", "render-bmp", "output/output.apj", "10", "output/screen2.png"}
+	executeCommand(t, cmd.RootCmd, args, "Screen rendered successfully")
+
+	apjFile := mpagd.NewAPJFile("output/output.apj")
+	if err := apjFile.ReadAPJ(); err != nil {
+		t.Fatalf("Error reading APJ file: %v", err)
+	}
+	if len(apjFile.Screens) != 11 || len(apjFile.SpriteInfo) == 0 {
+		t.Errorf("Expected 11 screens with their sprite positions, got %d screens", len(apjFile.Screens))
+	}
+
+	original, _ := os.ReadFile("output/screen.png")
+	imported, _ := os.ReadFile("output/screen2.png")
+	if string(original) != string(imported) {
+		t.Errorf("Imported screen does not match the original screen")
+	}
+
+	// Blocks are only reused on the same data, preferring the block type asked for
+	wall, deadly := mpagd.BlockTypeToId["WALLBLOCK"], mpagd.BlockTypeToId["DEADLYBLOCK"]
+	firstNew := 67 // The number of blocks in the test project
+	newBlocks := len(apjFile.Blocks) - firstNew
+	if newBlocks == 0 {
+		t.Fatalf("Expected new blocks for the cells that look like a block with other data")
+	}
+	for i := firstNew; i < firstNew+newBlocks; i++ {
+		if apjFile.Blocks[i].Type != wall {
+			t.Errorf("Expected new block %d to be a WALLBLOCK, got type %d", i, apjFile.Blocks[i].Type)
+		}
+		deadlyBlock := apjFile.Blocks[i]
+		deadlyBlock.ID, deadlyBlock.Type = uint8(len(apjFile.Blocks)), deadly
+		apjFile.Blocks = append(apjFile.Blocks, deadlyBlock)
+	}
+	for _, blockType := range []uint8{deadly, wall} {
+		added, _, err := apjFile.ImportScreenFromPNG("output/screen.png", blockType)
+		if err != nil || added != 0 {
+			t.Fatalf("Expected the screen to reuse the blocks, got %d new blocks, %v", added, err)
+		}
+		screen := apjFile.Screens[len(apjFile.Screens)-1]
+		for y, row := range screen.ScreenData {
+			for x, blockID := range row {
+				if original := apjFile.Screens[10].ScreenData[y][x]; int(original) >= firstNew && apjFile.Blocks[blockID].Type != blockType {
+					t.Errorf("Expected cell %d,%d to use a block of type %d, got block %d", x, y, blockType, blockID)
+				}
+			}
+		}
+	}
+	CleanOutputFolder()
+}
+
+// TestSpritePositionRoundTrip tests that sprite positions keep their screen when screens
+// without sprites are written, and that a project saved by MPAGD reads back unchanged
+func TestSpritePositionRoundTrip(t *testing.T) {
+	CleanOutputFolder()
+	args := []string{"project", "import", "output/output.apj", "testproject.agd", "--no-code"}
+	executeCommand(t, cmd.RootCmd, args, "AGD elements imported successfully")
+	apjFile := mpagd.NewAPJFile("output/output.apj")
+	if err := apjFile.ReadAPJ(); err != nil {
+		t.Fatalf("Error reading APJ file: %v", err)
+	}
+
+	// Keep the sprites of the first screen only, and add empty screens after the last one
+	spriteInfo := []mpagd.SpriteInfo{}
+	for _, spritePos := range apjFile.SpriteInfo {
+		if spritePos.Screen == 0 {
+			spriteInfo = append(spriteInfo, spritePos)
+		}
+	}
+	last := apjFile.SpriteInfo[len(apjFile.SpriteInfo)-1]
+	last.Screen = 2
+	apjFile.SpriteInfo = append(spriteInfo, last)
+	for range 2 {
+		if err := apjFile.InsertScreen(int(apjFile.NrOfScreens), 0); err != nil {
+			t.Fatalf("Error inserting screen: %v", err)
+		}
+	}
+	if err := apjFile.WriteAPJ("output/gaps.apj"); err != nil {
+		t.Fatalf("Error writing APJ file: %v", err)
+	}
+	gaps := mpagd.NewAPJFile("output/gaps.apj")
+	if err := gaps.ReadAPJ(); err != nil {
+		t.Fatalf("Error reading APJ file: %v", err)
+	}
+	if fmt.Sprint(gaps.SpriteInfo) != fmt.Sprint(apjFile.SpriteInfo) || gaps.NrOfScreens != apjFile.NrOfScreens {
+		t.Errorf("Expected the sprite positions %v, got %v", apjFile.SpriteInfo, gaps.SpriteInfo)
+	}
+
+	// A project saved by MPAGD must write back the same sprite positions and data after them
+	filePath := "splat.apj"
+	if _, err := os.Stat(filePath); err != nil {
+		t.Skipf("MPAGD project %s not found", filePath)
+	}
+	saved := mpagd.NewAPJFile(filePath)
+	if err := saved.ReadAPJ(); err != nil {
+		t.Fatalf("Error reading APJ file: %v", err)
+	}
+	if err := saved.WriteAPJ("output/splat.apj"); err != nil {
+		t.Fatalf("Error writing APJ file: %v", err)
+	}
+	written := mpagd.NewAPJFile("output/splat.apj")
+	if err := written.ReadAPJ(); err != nil {
+		t.Fatalf("Error reading APJ file: %v", err)
+	}
+	if differences := saved.CompareData(written); len(differences) > 0 {
+		t.Errorf("Differences found: %+v", differences)
+	}
+	CleanOutputFolder()
+}
+
+// TestRenderSpriteFrames tests rendering all the frames of a sprite and the animated GIF output
+func TestRenderSpriteFrames(t *testing.T) {
+	CleanOutputFolder()
+	args := []string{"project", "import", "output/output.apj", "testproject.agd", "--no-code"}
+	executeCommand(t, cmd.RootCmd, args, "AGD elements imported successfully")
+
+	args = []string{"sprites", "render-gif", "output/output.apj", "0", "2", "output/gif", "--delay", "20"}
+	executeCommand(t, cmd.RootCmd, args, "successfully rendered")
+
+	apjFile := mpagd.NewAPJFile("output/output.apj")
+	if err := apjFile.ReadAPJ(); err != nil {
+		t.Fatalf("Error reading APJ file: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		file, err := os.Open(fmt.Sprintf("output/gif/sprite_%d.gif", i))
+		if err != nil {
+			t.Fatalf("Error opening GIF file: %v", err)
+		}
+		anim, err := gif.DecodeAll(file)
+		file.Close()
+		if err != nil {
+			t.Fatalf("Error decoding GIF file: %v", err)
+		}
+		if len(anim.Image) != int(apjFile.Sprites[i].Frames) || anim.Delay[0] != 20 {
+			t.Errorf("Sprite %d GIF has %d frames, expected %d", i, len(anim.Image), apjFile.Sprites[i].Frames)
+		}
+	}
+
+	// A strip of all frames can be imported again as one sprite
+	args = []string{"sprites", "render-bmp", "output/output.apj", "0", "1", "output/strip.png", "--all-frames", "--seprate=false"}
+	executeCommand(t, cmd.RootCmd, args, "successfully rendered")
+
+	args = []string{"sprites", "import-png", "output/output.apj", "output/strip.png", "output/strip.apj", "--replace", "--gap", "1"}
+	executeCommand(t, cmd.RootCmd, args, "1 sprites imported successfully")
+
+	stripFile := mpagd.NewAPJFile("output/strip.apj")
+	if err := stripFile.ReadAPJ(); err != nil {
+		t.Fatalf("Error reading APJ file: %v", err)
+	}
+	if stripFile.Sprites[0].Frames != apjFile.Sprites[0].Frames {
+		t.Errorf("Expected %d frames, got %d", apjFile.Sprites[0].Frames, stripFile.Sprites[0].Frames)
+	}
+	CleanOutputFolder()
+}
+
+// TestProjectDiff tests the differences found between two projects
+func TestProjectDiff(t *testing.T) {
+	CleanOutputFolder()
+	args := []string{"project", "import", "output/output.apj", "testproject.agd", "--no-code"}
+	executeCommand(t, cmd.RootCmd, args, "AGD elements imported successfully")
+	args = []string{"project", "import", "output/other.apj", "testproject.agd", "--no-code"}
+	executeCommand(t, cmd.RootCmd, args, "AGD elements imported successfully")
+
+	args = []string{"project", "diff", "output/output.apj", "output/other.apj"}
+	executeCommand(t, cmd.RootCmd, args, "No differences")
+
+	args = []string{"messages", "edit", "output/other.apj", "0", "HELLO"}
+	executeCommand(t, cmd.RootCmd, args, "Message 0 updated successfully")
+
+	args = []string{"project", "diff", "output/output.apj", "output/other.apj"}
+	executeCommand(t, cmd.RootCmd, args, "~ Messages[0].Text: SPLAT -> HELLO")
+
+	self := mpagd.NewAPJFile("output/output.apj")
+	if err := self.ReadAPJ(); err != nil {
+		t.Fatalf("Error reading APJ file: %v", err)
+	}
+	other := mpagd.NewAPJFile("output/other.apj")
+	if err := other.ReadAPJ(); err != nil {
+		t.Fatalf("Error reading APJ file: %v", err)
+	}
+	other.Screens[1].ScreenData[2][3] = 9
+	other.Map.Map[0][1] = 255
+	diff := self.Diff(other)
+	paths := make([]string, 0, len(diff.Entries))
+	for _, entry := range diff.Entries {
+		paths = append(paths, entry.Path)
+	}
+	expected := "Screens[1].ScreenData[2][3],Map.Map[0][1],Messages[0].Text"
+	if strings.Join(paths, ",") != expected {
+		t.Errorf("Expected differences %s, got %s", expected, strings.Join(paths, ","))
+	}
+	CleanOutputFolder()
+}
+
+// TestValidateProject tests the validation of a good and a broken project
+func TestValidateProject(t *testing.T) {
+	CleanOutputFolder()
+	args := []string{"project", "import", "output/output.apj", "testproject.agd", "--no-code"}
+	executeCommand(t, cmd.RootCmd, args, "AGD elements imported successfully")
+
+	args = []string{"project", "validate", "output/output.apj"}
+	executeCommand(t, cmd.RootCmd, args, "output/output.apj is valid")
+
+	apjFile := mpagd.NewAPJFile("output/output.apj")
+	if err := apjFile.ReadAPJ(); err != nil {
+		t.Fatalf("Error reading APJ file: %v", err)
+	}
+	apjFile.Screens[0].ScreenData[1][2] = 200
+	apjFile.Map.Map[0][0] = 100
+	issues := apjFile.Validate()
+	if !mpagd.HasValidationErrors(issues) {
+		t.Fatalf("Expected validation errors, got none")
+	}
+	sections := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		sections = append(sections, issue.Section)
+	}
+	expected := "Screens,Map"
+	if strings.Join(sections, ",") != expected {
+		t.Errorf("Expected issues in %s, got %s", expected, strings.Join(sections, ","))
+	}
+	if err := apjFile.RenderScreenToBitmap(0, "output/screen.png", mpagd.ScreenRenderOptions{}); err == nil {
+		t.Errorf("Expected an error rendering a screen with a missing block")
+	}
+	CleanOutputFolder()
+}
+
+// TestDeleteBlocks tests deleting blocks and remapping the screens
+func TestDeleteBlocks(t *testing.T) {
+	CleanOutputFolder()
+	args := []string{"project", "import", "output/output.apj", "testproject.agd", "--no-code"}
+	executeCommand(t, cmd.RootCmd, args, "AGD elements imported successfully")
+	before := mpagd.NewAPJFile("output/output.apj")
+	if err := before.ReadAPJ(); err != nil {
+		t.Fatalf("Error reading APJ file: %v", err)
+	}
+
+	args = []string{"blocks", "delete", "output/output.apj", "1,3-4", "output/deleted.apj", "--substitute", "2"}
+	executeCommand(t, cmd.RootCmd, args, "3 blocks deleted successfully")
+	after := mpagd.NewAPJFile("output/deleted.apj")
+	if err := after.ReadAPJ(); err != nil {
+		t.Fatalf("Error reading APJ file: %v", err)
+	}
+
+	if int(after.NrOfBlocks) != len(before.Blocks)-3 {
+		t.Fatalf("Expected %d blocks, got %d", len(before.Blocks)-3, after.NrOfBlocks)
+	}
+	if !bytes.Equal(after.Blocks[3].Spectrum, before.Blocks[6].Spectrum) {
+		t.Errorf("Expected block 6 to become block 3")
+	}
+	// 0 stays, 1, 3 and 4 become the substitute 2 which is now 1, higher blocks shift down by 3
+	expected := map[uint8]uint8{0: 0, 1: 1, 2: 1, 3: 1, 4: 1}
+	for i, screen := range before.Screens {
+		for row, data := range screen.ScreenData {
+			for col, blockID := range data {
+				want, ok := expected[blockID]
+				if !ok {
+					want = blockID - 3
+				}
+				if got := after.Screens[i].ScreenData[row][col]; got != want {
+					t.Fatalf("Screen %d row %d column %d: expected block %d, got %d", i, row, col, want, got)
+				}
+			}
+		}
+	}
+	CleanOutputFolder()
+}
+
+// TestDeleteBlocksCode tests renumbering the PUTBLOCK statements in the event code when blocks are deleted
+func TestDeleteBlocksCode(t *testing.T) {
+	CleanOutputFolder()
+	args := []string{"project", "import", "output/output.apj", "testproject.agd", "--no-code=false"}
+	executeCommand(t, cmd.RootCmd, args, "21 event code files written")
+	code, err := os.ReadFile("output/output.a00")
+	if err != nil {
+		t.Fatalf("Error reading event file: %v", err)
+	}
+	if err := os.WriteFile("output/output.a00", append(code, "PUTBLOCK 5\nPUTBLOCK 2\nPUTBLOCK 1\nPUTBLOCK X\n"...), 0644); err != nil {
+		t.Fatalf("Error writing event file: %v", err)
+	}
+
+	// The code puts a block from a variable, so the blocks are only deleted with --force
+	args = []string{"blocks", "delete", "output/output.apj", "1,3-4", "output/deleted.apj", "--substitute", "2", "--force=false"}
+	output, _ := executeCommand(t, cmd.RootCmd, args, "puts the block X: PUTBLOCK X")
+	if !strings.Contains(output, "Blocks not deleted") {
+		t.Errorf("Expected the blocks not to be deleted without --force, got %s", output)
+	}
+	if _, err := os.Stat("output/deleted.apj"); !os.IsNotExist(err) {
+		t.Errorf("Expected no project to be written without --force")
+	}
+
+	// Block 5 shifts down by 3, the substitute 2 becomes 1 and so does the deleted block 1
+	args = []string{"blocks", "delete", "output/output.apj", "1,3-4", "output/deleted.apj", "--substitute", "2", "--force"}
+	executeCommand(t, cmd.RootCmd, args, "3 blocks deleted successfully")
+	code, err = os.ReadFile("output/deleted.a00")
+	if err != nil {
+		t.Fatalf("Error reading event file: %v", err)
+	}
+	if !strings.Contains(string(code), "PUTBLOCK 2\nPUTBLOCK 1\nPUTBLOCK 1\nPUTBLOCK X\n") {
+		t.Errorf("Expected the PUTBLOCK statements to be renumbered, got %s", code)
+	}
+	CleanOutputFolder()
+}
+
+// TestInsertDeleteSprites tests inserting and deleting sprites and renumbering the sprite positions
+func TestInsertDeleteSprites(t *testing.T) {
+	CleanOutputFolder()
+	args := []string{"project", "import", "output/output.apj", "testproject.agd", "--no-code"}
+	executeCommand(t, cmd.RootCmd, args, "AGD elements imported successfully")
+	original := mpagd.NewAPJFile("output/output.apj")
+	if err := original.ReadAPJ(); err != nil {
+		t.Fatalf("Error reading APJ file: %v", err)
+	}
+
+	args = []string{"sprites", "insert", "output/output.apj", "1", "output/inserted.apj", "--frames", "2"}
+	executeCommand(t, cmd.RootCmd, args, "Sprite 1 inserted successfully with 2 frames")
+	inserted := mpagd.NewAPJFile("output/inserted.apj")
+	if err := inserted.ReadAPJ(); err != nil {
+		t.Fatalf("Error reading APJ file: %v", err)
+	}
+	if inserted.NrOfSprites != original.NrOfSprites+1 || inserted.Sprites[1].Frames != 2 {
+		t.Fatalf("Expected %d sprites with a 2 frame sprite 1, got %d sprites", original.NrOfSprites+1, inserted.NrOfSprites)
+	}
+	if inserted.Sprites[2].OffSet != original.Sprites[1].OffSet+2 {
+		t.Errorf("Expected sprite 2 offset %d, got %d", original.Sprites[1].OffSet+2, inserted.Sprites[2].OffSet)
+	}
+	for i, spritePos := range original.SpriteInfo {
+		expected := spritePos.Image
+		if expected >= 1 {
+			expected++
+		}
+		if inserted.SpriteInfo[i].Image != expected {
+			t.Errorf("Sprite position %d: expected image %d, got %d", i, expected, inserted.SpriteInfo[i].Image)
+		}
+	}
+
+	// Deleting the inserted sprite gives back the original project
+	args = []string{"sprites", "delete", "output/inserted.apj", "1", "output/deleted.apj"}
+	executeCommand(t, cmd.RootCmd, args, "1 sprites deleted successfully")
+	deleted := mpagd.NewAPJFile("output/deleted.apj")
+	if err := deleted.ReadAPJ(); err != nil {
+		t.Fatalf("Error reading APJ file: %v", err)
+	}
+	if diff := original.Diff(deleted); len(diff.Entries) != 0 {
+		t.Errorf("Expected no differences after insert and delete, got %d", len(diff.Entries))
+	}
+
+	// A sprite placed on a screen is only deleted with its positions
+	placed := int(original.SpriteInfo[0].Image)
+	if _, err := original.DeleteSprites([]int{placed}, false, "output/output.apj"); err == nil {
+		t.Errorf("Expected an error deleting placed sprite %d", placed)
+	}
+	removed, err := original.DeleteSprites([]int{placed}, true, "output/output.apj")
+	if err != nil || removed == 0 {
+		t.Errorf("Expected sprite %d and its positions to be deleted, got %d positions, %v", placed, removed, err)
+	}
+	CleanOutputFolder()
+}
+
+// TestInsertDeleteSpritesCode tests renumbering the images in the event code when sprites are inserted and deleted
+func TestInsertDeleteSpritesCode(t *testing.T) {
+	CleanOutputFolder()
+	args := []string{"project", "import", "output/output.apj", "testproject.agd", "--no-code=false"}
+	executeCommand(t, cmd.RootCmd, args, "21 event code files written")
+	apjFile := mpagd.NewAPJFile("output/output.apj")
+	readCode := func() map[uint8]string {
+		code := make(map[uint8]string)
+		for id := uint8(0); id < mpagd.NrOfEvents; id++ {
+			if data, err := os.ReadFile(apjFile.EventFilePath(id)); err == nil {
+				code[id] = string(data)
+			}
+		}
+		return code
+	}
+	original := readCode()
+
+	// The code sets images from variables, so the sprite is only inserted with --force
+	args = []string{"sprites", "insert", "output/output.apj", "4", "--frames", "1", "--force=false"}
+	output, _ := executeCommand(t, cmd.RootCmd, args, "LET IMAGE = I")
+	if !strings.Contains(output, "Sprite not inserted") {
+		t.Errorf("Expected the sprite not to be inserted without --force, got %s", output)
+	}
+	if err := apjFile.ReadAPJ(); err != nil || apjFile.NrOfSprites != 19 {
+		t.Fatalf("Expected 19 sprites, got %d, %v", apjFile.NrOfSprites, err)
+	}
+
+	// Inserting sprite 4 moves the images from 4 up, the constants for images 0 to 3 keep their sprite
+	args = []string{"sprites", "insert", "output/output.apj", "4", "--frames", "1", "--force"}
+	executeCommand(t, cmd.RootCmd, args, "Sprite 4 inserted successfully")
+	var code strings.Builder
+	for _, content := range readCode() {
+		code.WriteString(content)
+	}
+	for _, expected := range []string{"IF IMAGE = 5", "IF IMAGE <> 17", "IF IMAGE = 19", "LET IMAGE = UP"} {
+		if !strings.Contains(code.String(), expected) {
+			t.Errorf("Expected %q in the event code after the insert", expected)
+		}
+	}
+	if strings.Contains(code.String(), "IF IMAGE = 4") || strings.Contains(code.String(), "IF IMAGE = 18") {
+		t.Errorf("Expected IMAGE 4 and 18 to be renumbered in the event code")
+	}
+	backups, err := apjFile.ListBackupProjectFiles("output/backups")
+	if err != nil || len(backups) != 1 {
+		t.Fatalf("Expected 1 backup, got %v, %v", backups, err)
+	}
+	if _, ok := readBackup(t, "output/backups/"+backups[0])["output.a00"]; !ok {
+		t.Errorf("Expected the backup to contain the event code")
+	}
+
+	// Deleting the inserted sprite gives back the original code
+	args = []string{"sprites", "delete", "output/output.apj", "4", "--force", "--remove-positions=false"}
+	executeCommand(t, cmd.RootCmd, args, "1 sprites deleted successfully")
+	for id, content := range readCode() {
+		if content != original[id] {
+			t.Errorf("Expected event file %d to be the same as before the insert", id)
+		}
+	}
+
+	// A deleted sprite used by the code is listed, and nothing is written without --force
+	args = []string{"sprites", "delete", "output/output.apj", "4", "output/deleted.apj", "--force=false"}
+	output, _ = executeCommand(t, cmd.RootCmd, args, "uses the image 4: IF IMAGE = 4")
+	if !strings.Contains(output, "Sprites not deleted") {
+		t.Errorf("Expected the sprites not to be deleted without --force, got %s", output)
+	}
+	if _, err := os.Stat("output/deleted.apj"); !os.IsNotExist(err) {
+		t.Errorf("Expected no project to be written without --force")
+	}
+	CleanOutputFolder()
+}
+
+// TestDuplicateInsertDeleteScreens tests copying, inserting and deleting screens and updating the map and sprite positions
+func TestDuplicateInsertDeleteScreens(t *testing.T) {
+	CleanOutputFolder()
+	args := []string{"project", "import", "output/output.apj", "testproject.agd", "--no-code"}
+	executeCommand(t, cmd.RootCmd, args, "AGD elements imported successfully")
+	original := mpagd.NewAPJFile("output/output.apj")
+	if err := original.ReadAPJ(); err != nil {
+		t.Fatalf("Error reading APJ file: %v", err)
+	}
+	screen := int(original.SpriteInfo[0].Screen)
+	spriteCount := 0
+	for _, spritePos := range original.SpriteInfo {
+		if int(spritePos.Screen) == screen {
+			spriteCount++
+		}
+	}
+
+	args = []string{"screens", "duplicate", "output/output.apj", fmt.Sprint(screen), "output/duplicate.apj", "--sprites"}
+	executeCommand(t, cmd.RootCmd, args, fmt.Sprintf("Screen %d copied successfully to screen %d", screen, original.NrOfScreens))
+	duplicate := mpagd.NewAPJFile("output/duplicate.apj")
+	if err := duplicate.ReadAPJ(); err != nil {
+		t.Fatalf("Error reading APJ file: %v", err)
+	}
+	if diff := original.Diff(duplicate); len(diff.Entries) != 2+spriteCount {
+		t.Errorf("Expected the screen count, the new screen and %d sprite positions, got %d differences", spriteCount, len(diff.Entries))
+	}
+	if fmt.Sprint(duplicate.Screens[original.NrOfScreens].ScreenData) != fmt.Sprint(original.Screens[screen].ScreenData) {
+		t.Errorf("Expected the copy to match screen %d", screen)
+	}
+
+	// Inserting a screen at the start moves every screen reference up by one
+	args = []string{"screens", "insert", "output/output.apj", "0", "output/inserted.apj"}
+	executeCommand(t, cmd.RootCmd, args, "Screen 0 inserted successfully")
+	inserted := mpagd.NewAPJFile("output/inserted.apj")
+	if err := inserted.ReadAPJ(); err != nil {
+		t.Fatalf("Error reading APJ file: %v", err)
+	}
+	if inserted.Map.StartScreen != original.Map.StartScreen+1 || inserted.SpriteInfo[0].Screen != original.SpriteInfo[0].Screen+1 {
+		t.Errorf("Expected the start screen and sprite positions to move up by one")
+	}
+
+	// Deleting the inserted screen gives back the original project
+	args = []string{"screens", "delete", "output/inserted.apj", "0", "output/deleted.apj"}
+	executeCommand(t, cmd.RootCmd, args, "1 screens deleted successfully")
+	deleted := mpagd.NewAPJFile("output/deleted.apj")
+	if err := deleted.ReadAPJ(); err != nil {
+		t.Fatalf("Error reading APJ file: %v", err)
+	}
+	if diff := original.Diff(deleted); len(diff.Entries) != 0 {
+		t.Errorf("Expected no differences after insert and delete, got %d", len(diff.Entries))
+	}
+
+	// Objects move with their room and go to the no room value when it is deleted
+	if len(original.Objects) == 0 {
+		t.Fatalf("Expected the project to have objects")
+	}
+	original.Objects[0].Spectrum[1] = 2
+	if err := original.InsertScreen(0, 0); err != nil {
+		t.Fatalf("Error inserting screen: %v", err)
+	}
+	if room := original.Objects[0].Spectrum[1]; room != 3 {
+		t.Errorf("Expected the object to move to room 3, got %d", room)
+	}
+	if _, moved, err := original.DeleteScreens([]int{3}); err != nil || moved != 1 || original.Objects[0].Spectrum[1] != mpagd.ObjectNoRoom {
+		t.Errorf("Expected the object to move to room %d, got room %d, %d moved, %v", mpagd.ObjectNoRoom, original.Objects[0].Spectrum[1], moved, err)
+	}
+	original = mpagd.NewAPJFile("output/output.apj")
+	if err := original.ReadAPJ(); err != nil {
+		t.Fatalf("Error reading APJ file: %v", err)
+	}
+
+	// A deleted screen is removed from the map along with its sprite positions
+	if _, _, err := original.DeleteScreens([]int{int(original.Map.StartScreen)}); err == nil {
+		t.Errorf("Expected an error deleting the start screen")
+	}
+	if screen != int(original.Map.StartScreen) {
+		removed, _, err := original.DeleteScreens([]int{screen})
+		if err != nil || removed != spriteCount {
+			t.Fatalf("Expected %d sprite positions removed, got %d, %v", spriteCount, removed, err)
+		}
+		for _, row := range original.Map.Map {
+			for _, screenID := range row {
+				if screenID != 255 && screenID >= original.NrOfScreens {
+					t.Errorf("Map uses screen %d, the project has %d screens", screenID, original.NrOfScreens)
+				}
+			}
+		}
+	}
+	CleanOutputFolder()
+}
+
+// TestUnusedPurge tests finding and purging the unused blocks and sprites
+func TestUnusedPurge(t *testing.T) {
+	CleanOutputFolder()
+	args := []string{"project", "import", "output/output.apj", "testproject.agd", "--no-code=false"}
+	executeCommand(t, cmd.RootCmd, args, "21 event code files written")
+
+	args = []string{"project", "unused", "output/output.apj", "--purge=false"}
+	executeCommand(t, cmd.RootCmd, args, "Unused blocks: 50,55,56,61")
+
+	// The code sets images from variables, so sprites are only purged with --force
+	args = []string{"project", "unused", "output/output.apj", "output/purged.apj", "--purge", "--force"}
+	executeCommand(t, cmd.RootCmd, args, "Purge completed successfully, 63 blocks and 18 sprites left")
+
+	purged := mpagd.NewAPJFile("output/purged.apj")
+	if err := purged.ReadAPJ(); err != nil {
+		t.Fatalf("Error reading APJ file: %v", err)
+	}
+	if issues := purged.Validate(); mpagd.HasValidationErrors(issues) {
+		t.Errorf("Expected a valid project after the purge, got %v", issues)
+	}
+	report, err := purged.FindUnused()
+	if err != nil {
+		t.Fatalf("Error finding unused blocks and sprites: %v", err)
+	}
+	if len(report.Blocks) != 0 || len(report.Sprites) != 0 {
+		t.Errorf("Expected no unused blocks or sprites after the purge, got %v and %v", report.Blocks, report.Sprites)
+	}
+
+	// Sprite 11 was deleted, so image 16 in the code becomes 15
+	code, err := os.ReadFile("output/purged.a05")
+	if err != nil {
+		t.Fatalf("Error reading event file: %v", err)
+	}
+	if !strings.Contains(string(code), "IF IMAGE = 15") || strings.Contains(string(code), "IF IMAGE = 16") {
+		t.Errorf("Expected IMAGE 16 to be renumbered to 15 in the event code")
+	}
+
+	// Blocks put from a variable are unknown, so no blocks or sprites are purged without --force
+	code, err = os.ReadFile("output/output.a05")
+	if err != nil {
+		t.Fatalf("Error reading event file: %v", err)
+	}
+	if err := os.WriteFile("output/output.a05", append(code, "PUTBLOCK X\n"...), 0644); err != nil {
+		t.Fatalf("Error writing event file: %v", err)
+	}
+	args = []string{"project", "unused", "output/output.apj", "--purge=false"}
+	executeCommand(t, cmd.RootCmd, args, "PUTBLOCK X")
+	args = []string{"project", "unused", "output/output.apj", "output/kept.apj", "--purge", "--force=false"}
+	executeCommand(t, cmd.RootCmd, args, "Purge completed successfully, 67 blocks and 19 sprites left")
+	CleanOutputFolder()
+}
+
+// TestDedupe tests merging identical blocks and sprites and updating their references
+func TestDedupe(t *testing.T) {
+	CleanOutputFolder()
+	args := []string{"project", "import", "output/output.apj", "testproject.agd", "--no-code"}
+	executeCommand(t, cmd.RootCmd, args, "AGD elements imported successfully")
+
+	// Add a copy of block 5 and sprite 4 and use them on screen 0
+	apjFile := mpagd.NewAPJFile("output/output.apj")
+	if err := apjFile.ReadAPJ(); err != nil {
+		t.Fatalf("Error reading APJ file: %v", err)
+	}
+	block := apjFile.Blocks[5]
+	block.ID = apjFile.NrOfBlocks
+	apjFile.Blocks = append(apjFile.Blocks, block)
+	apjFile.NrOfBlocks++
+	apjFile.Screens[0].ScreenData[1][1] = block.ID
+	sprite := apjFile.Sprites[4]
+	apjFile.Sprites = append(apjFile.Sprites, sprite)
+	apjFile.NrOfSprites++
+	apjFile.CalcOffset()
+	apjFile.SpriteInfo = append([]mpagd.SpriteInfo{{Image: apjFile.NrOfSprites - 1, Screen: 0, X: 16, Y: 16}}, apjFile.SpriteInfo...)
+	if err := apjFile.WriteAPJ("output/output.apj"); err != nil {
+		t.Fatalf("Error writing APJ file: %v", err)
+	}
+
+	args = []string{"blocks", "dedupe", "output/output.apj", "--dry-run", "--spectrum-only=false"}
+	executeCommand(t, cmd.RootCmd, args, fmt.Sprintf("Block %d is the same as block 5", block.ID))
+	args = []string{"blocks", "dedupe", "output/output.apj", "output/dedupe.apj", "--dry-run=false", "--force=false"}
+	executeCommand(t, cmd.RootCmd, args, "1 duplicate blocks merged successfully")
+
+	// Sprites are only merged with --force when the code sets images from variables
+	if err := os.WriteFile("output/dedupe.a05", []byte("EVENT SPRITETYPE5\nLET IMAGE = X\n"), 0644); err != nil {
+		t.Fatalf("Error writing event file: %v", err)
+	}
+	args = []string{"sprites", "dedupe", "output/dedupe.apj", "--dry-run=false", "--spectrum-only=false", "--force=false"}
+	executeCommand(t, cmd.RootCmd, args, "Sprites not merged as the code sets images from variables")
+	args = []string{"sprites", "dedupe", "output/dedupe.apj", "--dry-run=false", "--force"}
+	executeCommand(t, cmd.RootCmd, args, "1 duplicate sprites merged successfully")
+
+	dedupe := mpagd.NewAPJFile("output/dedupe.apj")
+	if err := dedupe.ReadAPJ(); err != nil {
+		t.Fatalf("Error reading APJ file: %v", err)
+	}
+	if dedupe.NrOfBlocks != block.ID || dedupe.NrOfSprites != apjFile.NrOfSprites-1 {
+		t.Errorf("Expected %d blocks and %d sprites, got %d and %d", block.ID, apjFile.NrOfSprites-1, dedupe.NrOfBlocks, dedupe.NrOfSprites)
+	}
+	if dedupe.Screens[0].ScreenData[1][1] != 5 || dedupe.SpriteInfo[0].Image != 4 {
+		t.Errorf("Expected the screen to use block 5 and sprite 4, got %d and %d", dedupe.Screens[0].ScreenData[1][1], dedupe.SpriteInfo[0].Image)
+	}
+
+	// A block with the same Spectrum data but other Timex data is only a duplicate with --spectrum-only
+	block = dedupe.Blocks[5]
+	block.ID = dedupe.NrOfBlocks
+	block.Timex = append([]uint8(nil), block.Timex...)
+	block.Timex[0] ^= 0xFF
+	dedupe.Blocks = append(dedupe.Blocks, block)
+	dedupe.NrOfBlocks++
+	if err := dedupe.WriteAPJ("output/dedupe.apj"); err != nil {
+		t.Fatalf("Error writing APJ file: %v", err)
+	}
+	args = []string{"blocks", "dedupe", "output/dedupe.apj", "--dry-run", "--spectrum-only=false"}
+	executeCommand(t, cmd.RootCmd, args, "0 duplicate blocks found")
+	args = []string{"blocks", "dedupe", "output/dedupe.apj", "--dry-run", "--spectrum-only"}
+	executeCommand(t, cmd.RootCmd, args, fmt.Sprintf("Block %d is the same as block 5", block.ID))
+	CleanOutputFolder()
+}
+
+// TestFindReplaceBlock tests finding and replacing a block across the screens
+func TestFindReplaceBlock(t *testing.T) {
+	CleanOutputFolder()
+	args := []string{"project", "import", "output/output.apj", "testproject.agd", "--no-code"}
+	executeCommand(t, cmd.RootCmd, args, "AGD elements imported successfully")
+
+	args = []string{"screens", "find-block", "output/output.apj", "5", "--screens="}
+	executeCommand(t, cmd.RootCmd, args, "Screen 7: 6 cells at 26,1 20,5 20,6 6,7 6,12 20,12")
+
+	// Only the cells inside the region of screen 7 are replaced
+	args = []string{"screens", "replace-block", "output/output.apj", "5", "6", "output/replaced.apj", "--screens", "7", "--region", "20,5,1,2"}
+	executeCommand(t, cmd.RootCmd, args, "Block 5 replaced with block 6 in 2 cells")
+
+	replaced := mpagd.NewAPJFile("output/replaced.apj")
+	if err := replaced.ReadAPJ(); err != nil {
+		t.Fatalf("Error reading APJ file: %v", err)
+	}
+	locations, err := replaced.FindBlocks([]int{5}, nil)
+	if err != nil {
+		t.Fatalf("Error finding block: %v", err)
+	}
+	if len(locations) != 9 {
+		t.Errorf("Expected 9 cells using block 5, got %d", len(locations))
+	}
+	if replaced.Screens[7].ScreenData[6][20] != 6 || replaced.Screens[7].ScreenData[12][20] != 5 {
+		t.Errorf("Expected only the cells in the region to be replaced")
+	}
+	CleanOutputFolder()
+}
+
+// TestSpriteFrames tests adding, copying, removing and reordering the frames of a sprite
+func TestSpriteFrames(t *testing.T) {
+	CleanOutputFolder()
+	args := []string{"project", "import", "output/output.apj", "testproject.agd", "--no-code"}
+	executeCommand(t, cmd.RootCmd, args, "AGD elements imported successfully")
+	original := mpagd.NewAPJFile("output/output.apj")
+	if err := original.ReadAPJ(); err != nil {
+		t.Fatalf("Error reading APJ file: %v", err)
+	}
+	frames := int(original.Sprites[0].Frames)
+
+	args = []string{"sprites", "frames", "add", "output/output.apj", "0", "output/frames.apj", "--position", "0"}
+	executeCommand(t, cmd.RootCmd, args, fmt.Sprintf("Frame added to sprite 0, it now has %d frames", frames+1))
+	added := mpagd.NewAPJFile("output/frames.apj")
+	if err := added.ReadAPJ(); err != nil {
+		t.Fatalf("Error reading APJ file: %v", err)
+	}
+	if len(added.Sprites[0].CPC) != frames+1 || len(added.Sprites[0].VZColour) != frames+1 {
+		t.Errorf("Expected every platform to have %d frames", frames+1)
+	}
+	if added.Sprites[1].OffSet != original.Sprites[1].OffSet+1 {
+		t.Errorf("Expected sprite 1 offset %d, got %d", original.Sprites[1].OffSet+1, added.Sprites[1].OffSet)
+	}
+	if !bytes.Equal(added.Sprites[0].Spectrum[0].ImageData, make([]uint8, 32)) || !bytes.Equal(added.Sprites[0].Spectrum[1].ImageData, original.Sprites[0].Spectrum[0].ImageData) {
+		t.Errorf("Expected a blank frame 0 followed by the original frames")
+	}
+
+	// Move the blank frame to the end and remove it to get back the original sprite
+	order := make([]string, 0, frames+1)
+	for i := 1; i <= frames; i++ {
+		order = append(order, fmt.Sprint(i))
+	}
+	order = append(order, "0")
+	args = []string{"sprites", "frames", "reorder", "output/frames.apj", "0", strings.Join(order, ",")}
+	executeCommand(t, cmd.RootCmd, args, "Frames of sprite 0 reordered")
+	args = []string{"sprites", "frames", "remove", "output/frames.apj", "0", fmt.Sprint(frames)}
+	executeCommand(t, cmd.RootCmd, args, fmt.Sprintf("Frame %d removed from sprite 0, it now has %d frames", frames, frames))
+	removed := mpagd.NewAPJFile("output/frames.apj")
+	if err := removed.ReadAPJ(); err != nil {
+		t.Fatalf("Error reading APJ file: %v", err)
+	}
+	if diff := original.Diff(removed); len(diff.Entries) != 0 {
+		t.Errorf("Expected no differences after adding, reordering and removing a frame, got %d", len(diff.Entries))
+	}
+
+	if err := removed.CopySpriteFrame(0, 0, -1); err != nil {
+		t.Fatalf("Error copying frame: %v", err)
+	}
+	if !bytes.Equal(removed.Sprites[0].Spectrum[1].ImageData, removed.Sprites[0].Spectrum[0].ImageData) {
+		t.Errorf("Expected frame 1 to be a copy of frame 0")
+	}
+	CleanOutputFolder()
+}
+
+// TestSplitMergeSprites tests splitting a sprite into single frame sprites and merging them back
+func TestSplitMergeSprites(t *testing.T) {
+	CleanOutputFolder()
+	args := []string{"project", "import", "output/output.apj", "testproject.agd", "--no-code"}
+	executeCommand(t, cmd.RootCmd, args, "AGD elements imported successfully")
+	original := mpagd.NewAPJFile("output/output.apj")
+	if err := original.ReadAPJ(); err != nil {
+		t.Fatalf("Error reading APJ file: %v", err)
+	}
+	frames := int(original.Sprites[2].Frames)
+
+	args = []string{"sprites", "split", "output/output.apj", "2", "output/split.apj"}
+	executeCommand(t, cmd.RootCmd, args, fmt.Sprintf("Sprite 2 split into sprites 2 to %d", 1+frames))
+	split := mpagd.NewAPJFile("output/split.apj")
+	if err := split.ReadAPJ(); err != nil {
+		t.Fatalf("Error reading APJ file: %v", err)
+	}
+	if int(split.NrOfSprites) != int(original.NrOfSprites)+frames-1 {
+		t.Fatalf("Expected %d sprites, got %d", int(original.NrOfSprites)+frames-1, split.NrOfSprites)
+	}
+	for i := 0; i < frames; i++ {
+		if split.Sprites[2+i].Frames != 1 || !bytes.Equal(split.Sprites[2+i].Spectrum[0].ImageData, original.Sprites[2].Spectrum[i].ImageData) {
+			t.Errorf("Expected sprite %d to be frame %d of sprite 2", 2+i, i)
+		}
+	}
+	for i, spritePos := range original.SpriteInfo {
+		expected := int(spritePos.Image)
+		if expected > 2 {
+			expected += frames - 1
+		}
+		if int(split.SpriteInfo[i].Image) != expected {
+			t.Errorf("Sprite position %d: expected image %d, got %d", i, expected, split.SpriteInfo[i].Image)
+		}
+	}
+
+	// Merging the split sprites gives back the original project
+	args = []string{"sprites", "merge", "output/split.apj", fmt.Sprintf("2-%d", 1+frames), "output/merged.apj"}
+	executeCommand(t, cmd.RootCmd, args, fmt.Sprintf("%d sprites merged into sprite 2, it now has %d frames", frames, frames))
+	merged := mpagd.NewAPJFile("output/merged.apj")
+	if err := merged.ReadAPJ(); err != nil {
+		t.Fatalf("Error reading APJ file: %v", err)
+	}
+	if diff := original.Diff(merged); len(diff.Entries) != 0 {
+		t.Errorf("Expected no differences after splitting and merging, got %d", len(diff.Entries))
+	}
+
+	// Merging into a sprite listed after a lower sprite moves the joined sprite down by one
+	frames = int(original.Sprites[18].Frames) + int(original.Sprites[4].Frames)
+	args = []string{"sprites", "merge", "output/output.apj", "18,4", "output/merged.apj"}
+	executeCommand(t, cmd.RootCmd, args, fmt.Sprintf("2 sprites merged into sprite 17, it now has %d frames", frames))
+	CleanOutputFolder()
+}
+
+// TestRotateSpriteFrames tests adding the rotations of a sprite as extra frames
+func TestRotateSpriteFrames(t *testing.T) {
+	CleanOutputFolder()
+	args := []string{"project", "import", "output/output.apj", "testproject.agd", "--no-code"}
+	executeCommand(t, cmd.RootCmd, args, "AGD elements imported successfully")
+	original := mpagd.NewAPJFile("output/output.apj")
+	if err := original.ReadAPJ(); err != nil {
+		t.Fatalf("Error reading APJ file: %v", err)
+	}
+	frames := int(original.Sprites[2].Frames)
+
+	// Four clockwise rotations end where they started
+	args = []string{"sprites", "rotate", "cw", "output/output.apj", "2", "output/cw.apj", "--frames", "--add=false", "--repeat", "4", "--start", "0", "--end", "0"}
+	executeCommand(t, cmd.RootCmd, args, "Rotation completed for sprite number 2")
+	rotated := mpagd.NewAPJFile("output/cw.apj")
+	if err := rotated.ReadAPJ(); err != nil {
+		t.Fatalf("Error reading APJ file: %v", err)
+	}
+	sprite := rotated.Sprites[2]
+	if int(sprite.Frames) != frames*5 || rotated.NrOfSprites != original.NrOfSprites {
+		t.Fatalf("Expected sprite 2 to have %d frames in %d sprites, got %d frames in %d sprites", frames*5, original.NrOfSprites, sprite.Frames, rotated.NrOfSprites)
+	}
+	changed := false
+	for i := 0; i < frames; i++ {
+		if !bytes.Equal(sprite.Spectrum[frames*4+i].ImageData, original.Sprites[2].Spectrum[i].ImageData) ||
+			!bytes.Equal(sprite.Timex[frames*4+i].ImageData, original.Sprites[2].Timex[i].ImageData) {
+			t.Errorf("Expected frame %d to be the same as frame %d after four rotations", frames*4+i, i)
+		}
+		changed = changed || !bytes.Equal(sprite.Spectrum[frames+i].ImageData, original.Sprites[2].Spectrum[i].ImageData)
+	}
+	if !changed {
+		t.Errorf("Expected the first rotation to change the frames")
+	}
+
+	// A counter-clockwise rotation undoes a clockwise one
+	args = []string{"sprites", "rotate", "ccw", "output/cw.apj", "2", "output/ccw.apj", "--frames", "--add=false", "--repeat", "1", "--start", "0", "--end", "0"}
+	executeCommand(t, cmd.RootCmd, args, "Rotation completed for sprite number 2")
+	undone := mpagd.NewAPJFile("output/ccw.apj")
+	if err := undone.ReadAPJ(); err != nil {
+		t.Fatalf("Error reading APJ file: %v", err)
+	}
+	for i := 0; i < frames; i++ {
+		if !bytes.Equal(undone.Sprites[2].Spectrum[frames*6+i].ImageData, original.Sprites[2].Spectrum[i].ImageData) {
+			t.Errorf("Expected frame %d to be the same as frame %d after rotating back", frames*6+i, i)
+		}
+	}
+
+	// The top right pixel turns to the top left counter-clockwise and to the bottom right clockwise
+	pixel := make([]uint8, 32)
+	pixel[1] = 0x01
+	for _, direction := range []struct {
+		ccw   bool
+		index int
+		value uint8
+	}{{true, 0, 0x80}, {false, 31, 0x01}} {
+		original.Sprites[0].Spectrum[0].ImageData = append([]uint8(nil), pixel...)
+		if _, err := original.RotateSprite(0, direction.ccw, false); err != nil {
+			t.Fatalf("Error rotating sprite: %v", err)
+		}
+		expected := make([]uint8, 32)
+		expected[direction.index] = direction.value
+		if !bytes.Equal(original.Sprites[0].Spectrum[0].ImageData, expected) {
+			t.Errorf("Expected the pixel in byte %d with ccw %v, got %v", direction.index, direction.ccw, original.Sprites[0].Spectrum[0].ImageData)
+		}
+	}
+	CleanOutputFolder()
+}
+
+// TestFlipBlocksSprites tests mirroring blocks and every frame of a sprite
+func TestFlipBlocksSprites(t *testing.T) {
+	CleanOutputFolder()
+	args := []string{"project", "import", "output/output.apj", "testproject.agd", "--no-code"}
+	executeCommand(t, cmd.RootCmd, args, "AGD elements imported successfully")
+	original := mpagd.NewAPJFile("output/output.apj")
+	if err := original.ReadAPJ(); err != nil {
+		t.Fatalf("Error reading APJ file: %v", err)
+	}
+
+	args = []string{"sprites", "flip", "h", "output/output.apj", "2", "output/flipped.apj", "--add", "--repeat", "1", "--start", "0", "--end", "0"}
+	executeCommand(t, cmd.RootCmd, args, "Flip completed for sprite number 2")
+	args = []string{"blocks", "flip", "v", "output/flipped.apj", "5", "--add=false", "--repeat", "1", "--start", "0", "--end", "0"}
+	executeCommand(t, cmd.RootCmd, args, "Flip completed for block number 5")
+	flipped := mpagd.NewAPJFile("output/flipped.apj")
+	if err := flipped.ReadAPJ(); err != nil {
+		t.Fatalf("Error reading APJ file: %v", err)
+	}
+	if flipped.NrOfSprites != original.NrOfSprites+1 {
+		t.Fatalf("Expected %d sprites, got %d", original.NrOfSprites+1, flipped.NrOfSprites)
+	}
+	mirror := flipped.Sprites[original.NrOfSprites]
+	if mirror.Frames != original.Sprites[2].Frames {
+		t.Fatalf("Expected the flipped sprite to have %d frames, got %d", original.Sprites[2].Frames, mirror.Frames)
+	}
+	for frame, data := range original.Sprites[2].Spectrum {
+		for row := 0; row < 16; row++ {
+			if mirror.Spectrum[frame].ImageData[row*2] != bits.Reverse8(data.ImageData[row*2+1]) ||
+				mirror.Spectrum[frame].ImageData[row*2+1] != bits.Reverse8(data.ImageData[row*2]) {
+				t.Errorf("Expected row %d of frame %d to be mirrored", row, frame)
+			}
+		}
+	}
+	block := original.Blocks[5].Spectrum
+	for row := 0; row < 8; row++ {
+		if flipped.Blocks[5].Spectrum[row] != block[7-row] {
+			t.Errorf("Expected row %d of block 5 to be row %d of the original", row, 7-row)
+		}
+	}
+	if flipped.Blocks[5].Spectrum[8] != block[8] {
+		t.Errorf("Expected the attribute of block 5 to be kept")
+	}
+
+	// Flipping twice gives back the original sprite
+	args = []string{"sprites", "flip", "v", "output/output.apj", "2", "output/twice.apj", "--add=false", "--repeat", "2", "--start", "0", "--end", "0"}
+	executeCommand(t, cmd.RootCmd, args, "Flip completed for sprite number 2")
+	twice := mpagd.NewAPJFile("output/twice.apj")
+	if err := twice.ReadAPJ(); err != nil {
+		t.Fatalf("Error reading APJ file: %v", err)
+	}
+	if diff := original.Diff(twice); len(diff.Entries) != 0 {
+		t.Errorf("Expected no differences after flipping twice, got %d", len(diff.Entries))
+	}
+	CleanOutputFolder()
+}
+
+// TestSpriteBlocksConversion tests cutting a sprite frame into blocks and building it again
+func TestSpriteBlocksConversion(t *testing.T) {
+	CleanOutputFolder()
+	args := []string{"project", "import", "output/output.apj", "testproject.agd", "--no-code"}
+	executeCommand(t, cmd.RootCmd, args, "AGD elements imported successfully")
+	original := mpagd.NewAPJFile("output/output.apj")
+	if err := original.ReadAPJ(); err != nil {
+		t.Fatalf("Error reading APJ file: %v", err)
+	}
+	first := len(original.Blocks)
+
+	args = []string{"blocks", "from-sprite", "output/output.apj", "2", "0", "output/converted.apj", "--type", "WALLBLOCK", "--attr", "66"}
+	executeCommand(t, cmd.RootCmd, args, fmt.Sprintf("Frame 0 of sprite 2 cut into blocks %d to %d", first, first+3))
+	blocks := []string{strconv.Itoa(first), strconv.Itoa(first + 1), strconv.Itoa(first + 2), strconv.Itoa(first + 3)}
+	args = append([]string{"sprites", "from-blocks", "output/converted.apj"}, append(blocks, "--sprite", "-1")...)
+	executeCommand(t, cmd.RootCmd, args, fmt.Sprintf("Frame 0 of sprite %d built from blocks", original.NrOfSprites))
+	args = append([]string{"sprites", "from-blocks", "output/converted.apj"}, append(blocks, "--sprite", "2")...)
+	executeCommand(t, cmd.RootCmd, args, fmt.Sprintf("Frame %d of sprite 2 built from blocks", original.Sprites[2].Frames))
+
+	converted := mpagd.NewAPJFile("output/converted.apj")
+	if err := converted.ReadAPJ(); err != nil {
+		t.Fatalf("Error reading APJ file: %v", err)
+	}
+	if len(converted.Blocks) != first+4 {
+		t.Fatalf("Expected %d blocks, got %d", first+4, len(converted.Blocks))
+	}
+	for _, block := range converted.Blocks[first:] {
+		if block.Type != mpagd.BlockTypeToId["WALLBLOCK"] || block.Spectrum[8] != 66 {
+			t.Errorf("Expected block %d to be a WALLBLOCK with attribute 66, got type %d and attribute %d", block.ID, block.Type, block.Spectrum[8])
+		}
+	}
+	frame := original.Sprites[2].Spectrum[0].ImageData
+	if row := converted.Blocks[first+3].Spectrum[0]; row != frame[17] {
+		t.Errorf("Expected the first row of the bottom right block to be %d, got %d", frame[17], row)
+	}
+	newSprite := converted.Sprites[original.NrOfSprites]
+	if newSprite.Frames != 1 || !bytes.Equal(newSprite.Spectrum[0].ImageData, frame) {
+		t.Errorf("Expected sprite %d to be frame 0 of sprite 2", original.NrOfSprites)
+	}
+	extended := converted.Sprites[2]
+	if extended.Frames != original.Sprites[2].Frames+1 || !bytes.Equal(extended.Spectrum[extended.Frames-1].ImageData, frame) {
+		t.Errorf("Expected the last frame of sprite 2 to be a copy of frame 0")
+	}
+	CleanOutputFolder()
+}
+
+// TestRenderMapToBitmap tests rendering the whole map to a PNG image
+func TestRenderMapToBitmap(t *testing.T) {
+	CleanOutputFolder()
+	args := []string{"project", "import", "output/output.apj", "testproject.agd", "--no-code"}
+	executeCommand(t, cmd.RootCmd, args, "AGD elements imported successfully")
+	args = []string{"map", "render-bmp", "output/output.apj", "output/map.png", "--labels", "--grid"}
+	executeCommand(t, cmd.RootCmd, args, "Map rendered successfully")
+
+	apjFile := mpagd.NewAPJFile("output/output.apj")
+	if err := apjFile.ReadAPJ(); err != nil {
+		t.Fatalf("Error reading APJ file: %v", err)
+	}
+	file, err := os.Open("output/map.png")
+	if err != nil {
+		t.Fatalf("Error opening map image: %v", err)
+	}
+	defer file.Close()
+	img, err := png.Decode(file)
+	if err != nil {
+		t.Fatalf("Error decoding map image: %v", err)
+	}
+
+	width, height := int(apjFile.Windows.Width)*8, int(apjFile.Windows.Height)*8
+	rows, columns := len(apjFile.Map.Map), len(apjFile.Map.Map[0])
+	if img.Bounds().Dx() != columns*(width+1)+1 || img.Bounds().Dy() != rows*(height+1)+1 {
+		t.Fatalf("Expected a %dx%d image, got %dx%d", columns*(width+1)+1, rows*(height+1)+1, img.Bounds().Dx(), img.Bounds().Dy())
+	}
+	startX := 1 + int(apjFile.Map.StartColumn)*(width+1)
+	startY := 1 + int(apjFile.Map.StartRow)*(height+1)
+	if r, g, b, _ := img.At(startX+width/2, startY).RGBA(); r>>8 != 255 || g>>8 != 255 || b>>8 != 0 {
+		t.Errorf("Expected the start screen to be outlined in yellow")
+	}
+	for y, row := range apjFile.Map.Map {
+		for x, screen := range row {
+			if screen != 255 {
+				continue
+			}
+			// The first pixel of an empty cell is on a hatch line
+			if r, g, b, _ := img.At(1+x*(width+1), 1+y*(height+1)).RGBA(); r>>8 != 64 || g>>8 != 64 || b>>8 != 64 {
+				t.Errorf("Expected map cell %d,%d to be hatched", x, y)
+			}
+		}
+	}
+
+	// Without colour the empty cell in the top left corner is hatched lines, not a solid block
+	var mono bytes.Buffer
+	if err := apjFile.RenderMapToTerminal(&mono, mpagd.MapRenderOptions{}, false); err != nil {
+		t.Fatalf("Error rendering map: %v", err)
+	}
+	if line := []rune(strings.SplitN(mono.String(), "\n", 2)[0]); string(line[:8]) != "▀      ▄" {
+		t.Errorf("Expected the empty map cell to be hatched, got %q", string(line[:8]))
+	}
+	CleanOutputFolder()
+}
+
+// TestRenderScreenOverlays tests drawing the sprites and objects on top of a screen render
+func TestRenderScreenOverlays(t *testing.T) {
+	CleanOutputFolder()
+	args := []string{"project", "import", "output/output.apj", "testproject.agd", "--no-code"}
+	executeCommand(t, cmd.RootCmd, args, "AGD elements imported successfully")
+	args = []string{"screens", "render-bmp", "output/output.apj", "0", "output/screen.png", "--sprites", "--objects"}
+	executeCommand(t, cmd.RootCmd, args, "Screen rendered successfully")
+
+	apjFile := mpagd.NewAPJFile("output/output.apj")
+	if err := apjFile.ReadAPJ(); err != nil {
+		t.Fatalf("Error reading APJ file: %v", err)
+	}
+	readImage := func(filePath string) image.Image {
+		file, err := os.Open(filePath)
+		if err != nil {
+			t.Fatalf("Error opening image: %v", err)
+		}
+		defer file.Close()
+		img, err := png.Decode(file)
+		if err != nil {
+			t.Fatalf("Error decoding image: %v", err)
+		}
+		return img
+	}
+	// firstPixel returns the image position of the first set pixel of 16x16 sprite data
+	firstPixel := func(data []uint8, x, y uint8) (int, int) {
+		for i, b := range data {
+			for bit := 0; bit < 8; bit++ {
+				if b>>(7-bit)&1 == 1 {
+					return int(y) - int(apjFile.Windows.Left)*8 + (i%2)*8 + bit, int(x) - int(apjFile.Windows.Top)*8 + i/2
+				}
+			}
+		}
+		t.Fatalf("Expected the sprite data to have a set pixel")
+		return 0, 0
+	}
+
+	// The first sprite on screen 0 is drawn in white at its position
+	img := readImage("output/screen.png")
+	for _, spritePos := range apjFile.SpriteInfo {
+		if spritePos.Screen != 0 {
+			continue
+		}
+		x, y := firstPixel(apjFile.Sprites[spritePos.Image].Spectrum[0].ImageData, spritePos.X, spritePos.Y)
+		if r, g, b, _ := img.At(x, y).RGBA(); r>>8 != 255 || g>>8 != 255 || b>>8 != 255 {
+			t.Errorf("Expected sprite %d to be drawn at %d,%d", spritePos.Image, x, y)
+		}
+		break
+	}
+
+	// An object moved to screen 0 is drawn in its ink colour
+	object := apjFile.Objects[0].Spectrum
+	object[0], object[1], object[2], object[3] = 66, 0, 80, 80
+	object[4] = 0x80
+	if err := apjFile.RenderScreenToBitmap(0, "output/object.png", mpagd.ScreenRenderOptions{Objects: true}); err != nil {
+		t.Fatalf("Error rendering screen: %v", err)
+	}
+	x, y := firstPixel(object[4:36], 80, 80)
+	if r, g, b, _ := readImage("output/object.png").At(x, y).RGBA(); r>>8 != 255 || g>>8 != 0 || b>>8 != 0 {
+		t.Errorf("Expected the object to be drawn in bright red at %d,%d", x, y)
+	}
+	CleanOutputFolder()
+}
+
+// TestRenderScreenToTerminal tests rendering a screen to the terminal with and without colour
+func TestRenderScreenToTerminal(t *testing.T) {
+	CleanOutputFolder()
+	args := []string{"project", "import", "output/output.apj", "testproject.agd", "--no-code"}
+	executeCommand(t, cmd.RootCmd, args, "AGD elements imported successfully")
+	apjFile := mpagd.NewAPJFile("output/output.apj")
+	if err := apjFile.ReadAPJ(); err != nil {
+		t.Fatalf("Error reading APJ file: %v", err)
+	}
+	lines := int(apjFile.Windows.Height) * 4
+
+	var colour bytes.Buffer
+	if err := apjFile.RenderScreenToTerminal(&colour, 0, mpagd.ScreenRenderOptions{}, true); err != nil {
+		t.Fatalf("Error rendering screen: %v", err)
+	}
+	if strings.Count(colour.String(), "\n") != lines {
+		t.Errorf("Expected %d lines, got %d", lines, strings.Count(colour.String(), "\n"))
+	}
+	// The paper of the first block is used as the background of the first character
+	_, paper := mpagd.SpectrumAttrToColors(apjFile.Blocks[apjFile.Screens[0].ScreenData[0][0]].Spectrum[8])
+	r, g, b, _ := paper.RGBA()
+	if !strings.Contains(colour.String(), fmt.Sprintf("\x1b[48;2;%d;%d;%dm", r>>8, g>>8, b>>8)) {
+		t.Errorf("Expected the Spectrum paper colour in the output")
+	}
+
+	var mono bytes.Buffer
+	if err := apjFile.RenderScreenToTerminal(&mono, 0, mpagd.ScreenRenderOptions{}, false); err != nil {
+		t.Fatalf("Error rendering screen: %v", err)
+	}
+	if strings.Contains(mono.String(), "\x1b") || strings.Count(mono.String(), "\n") != lines {
+		t.Errorf("Expected %d lines without colour codes", lines)
+	}
+	// Without colour the ink of the first block is drawn and its paper is left clear, whatever its colours
+	firstBlock := apjFile.Blocks[apjFile.Screens[0].ScreenData[0][0]]
+	firstBlock.Spectrum = append([]uint8{0xF0, 0xCC}, firstBlock.Spectrum[2:]...)
+	firstBlock.Spectrum[8] = 0x38 // black ink on white paper
+	apjFile.Blocks[apjFile.Screens[0].ScreenData[0][0]] = firstBlock
+	mono.Reset()
+	if err := apjFile.RenderScreenToTerminal(&mono, 0, mpagd.ScreenRenderOptions{}, false); err != nil {
+		t.Fatalf("Error rendering screen: %v", err)
+	}
+	if !strings.HasPrefix(mono.String(), "██▀▀▄▄  ") {
+		t.Errorf("Expected the first block to start with the ink of its bitmap, got %q", string([]rune(mono.String())[:8]))
+	}
+	if err := apjFile.RenderScreenToTerminal(&mono, uint8(len(apjFile.Screens)), mpagd.ScreenRenderOptions{}, false); err == nil {
+		t.Errorf("Expected an error for a screen that does not exist")
+	}
+	CleanOutputFolder()
+}
+
+func TestWriteInlineImage(t *testing.T) {
+	CleanOutputFolder()
+	args := []string{"project", "import", "output/output.apj", "testproject.agd", "--no-code"}
+	executeCommand(t, cmd.RootCmd, args, "AGD elements imported successfully")
+	apjFile := mpagd.NewAPJFile("output/output.apj")
+	if err := apjFile.ReadAPJ(); err != nil {
+		t.Fatalf("Error reading APJ file: %v", err)
+	}
+	img, err := apjFile.RenderScreenImage(0, mpagd.ScreenRenderOptions{})
+	if err != nil {
+		t.Fatalf("Error rendering screen: %v", err)
+	}
+	width, height := img.Bounds().Dx()*2, img.Bounds().Dy()*2
+
+	// The Kitty chunks joined together are the scaled image as a PNG
+	var kitty bytes.Buffer
+	if err := mpagd.WriteInlineImage(&kitty, img, mpagd.InlineKitty, 2); err != nil {
+		t.Fatalf("Error writing Kitty image: %v", err)
+	}
+	if !strings.HasPrefix(kitty.String(), "\x1b_Ga=T,f=100,m=1;") || !strings.HasSuffix(kitty.String(), "\x1b\\\n") {
+		t.Fatalf("Expected a chunked Kitty graphics sequence")
+	}
+	var payload strings.Builder
+	for _, chunk := range strings.Split(strings.TrimSuffix(kitty.String(), "\x1b\\\n"), "\x1b\\") {
+		payload.WriteString(chunk[strings.Index(chunk, ";")+1:])
+	}
+	encoded, err := base64.StdEncoding.DecodeString(payload.String())
+	if err != nil {
+		t.Fatalf("Error decoding Kitty payload: %v", err)
+	}
+	decoded, err := png.Decode(bytes.NewReader(encoded))
+	if err != nil {
+		t.Fatalf("Error decoding Kitty PNG: %v", err)
+	}
+	if decoded.Bounds().Dx() != width || decoded.Bounds().Dy() != height {
+		t.Errorf("Expected a %dx%d image, got %v", width, height, decoded.Bounds())
+	}
+
+	var sixel bytes.Buffer
+	if err := mpagd.WriteInlineImage(&sixel, img, mpagd.InlineSixel, 2); err != nil {
+		t.Fatalf("Error writing Sixel image: %v", err)
+	}
+	if !strings.HasPrefix(sixel.String(), fmt.Sprintf("\x1bPq\"1;1;%d;%d#0;2;", width, height)) || !strings.HasSuffix(sixel.String(), "-\x1b\\\n") {
+		t.Fatalf("Expected a Sixel sequence with the image size")
+	}
+	if bands := strings.Count(sixel.String(), "-"); bands != (height+5)/6 {
+		t.Errorf("Expected %d sixel bands, got %d", (height+5)/6, bands)
+	}
+
+	t.Setenv("KITTY_WINDOW_ID", "")
+	t.Setenv("TERM_PROGRAM", "")
+	t.Setenv("WT_SESSION", "")
+	t.Setenv("TERM", "foot")
+	if protocol, err := mpagd.DetectInlineProtocol(); err != nil || protocol != mpagd.InlineSixel {
+		t.Errorf("Expected Sixel for foot, got %q %v", protocol, err)
+	}
+	t.Setenv("TERM", "xterm-kitty")
+	if protocol, err := mpagd.DetectInlineProtocol(); err != nil || protocol != mpagd.InlineKitty {
+		t.Errorf("Expected Kitty for xterm-kitty, got %q %v", protocol, err)
+	}
+	t.Setenv("TERM", "dumb")
+	if err := mpagd.WriteInlineImage(&sixel, img, mpagd.InlineAuto, 1); err == nil {
+		t.Errorf("Expected an error when no protocol is detected")
+	}
+	CleanOutputFolder()
+}
+
 // TestReadProjectWriteCompare tests the read and write functionality of the APJ file
 func TestReadProjectWriteCompare(t *testing.T) {
 	CleanOutputFolder()
@@ -234,7 +1857,7 @@ func TestReadProjectImportWriteCompare(t *testing.T) {
 	agdFilePath := "testproject.agd"
 	opt := mpagd.CreateImportOptions()
 	opt.SetOwOptionsFalse()
-	opt.SetIgnoreOptions(true, true, false, true, true, false, true, true, true)
+	opt.SetIgnoreOptions(true, true, false, true, true, false, true, true, true, true)
 	apjFile.ImportAGD(agdFilePath, opt)
 
 	outputFilePath := "output/output.apj" // Replace with the other file path